@@ -11,7 +11,6 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/robfig/cron/v3"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -46,12 +45,15 @@ func (a *App) Startup(ctx context.Context) {
 	utils.SetAppContext(ctx)
 
 	// Check if the symlink is valid, if not, create it
+	// This also restores links left pointing at an override configuration
 	paths, err := model.GetConfigPaths()
 	if err == nil {
 		for _, path := range paths {
 			utils.CheckLink(path[0], path[1])
 		}
 	}
+	service.CleanupOverrides()
+	go service.CleanupAllRunLogs()
 
 	// Start file watcher for instance configuration files
 	fileWatcher := controller.GetFileWatcher()
@@ -91,87 +93,36 @@ func (a App) DomReady(ctx context.Context) {
 		utils.Logger.Error("Failed to load settings:", err)
 	}
 
-	var instances []model.InstanceInfo
-	if err := model.GetAllInstances(&instances); err != nil {
-		utils.Logger.Error("Failed to get all instances:", err)
-		return
-	}
+	// Deal with task processes left behind before any instance can start again
+	service.GetServiceManager().SchedulerService().HandleOrphans()
 
-	c := cron.New()
-	for _, instance := range instances {
-		if instance.Ready && instance.CronExpr != "" {
-			entryID, err := c.AddFunc(instance.CronExpr, func() {
-				service.GetServiceManager().SchedulerService().StartOne(instance.Name)
-			})
-			if err != nil {
-				utils.Logger.Errorf("[%s]: Failed to add cron job: %v", instance.Name, err)
-			} else {
-				entry := c.Entry(entryID)
-				nextRun := entry.Schedule.Next(time.Now()).Format("2006-01-02 15:04:05")
-				utils.Logger.Infof("[%s]: Cron job added: %s, next run at %s", instance.Name, instance.CronExpr, nextRun)
-			}
-		}
-	}
+	// Register cron jobs, they follow later configuration changes
+	service.GetServiceManager().CronService().Start()
+	service.GetServiceManager().SchedulerService().ReportInterrupted()
 
 	time.Sleep(3 * time.Second)
-	scheduler := model.GetScheduler()
 	// Handle runOnStartup setting
 	if settings.RunOnStartup {
 		utils.Logger.Info("Run on startup is enabled, starting scheduler")
+		scheduler := model.GetScheduler()
 		scheduler.AutoClose = true
-		go service.GetServiceManager().SchedulerService().StartAll()
-	} else if settings.SchedulerCron != "" {
-		// If runOnStartup is false but schedulerCron is set, use cron scheduling
-		scheduler.CronExpr = settings.SchedulerCron
-		entryID, err := c.AddFunc(scheduler.CronExpr, func() {
-			scheduler.AutoClose = true
-			service.GetServiceManager().SchedulerService().StartAll()
-		})
-		if err != nil {
-			utils.Logger.Errorf("Scheduler failed to add cron job: %v", err)
-		} else {
-			entry := c.Entry(entryID)
-			nextRun := entry.Schedule.Next(time.Now()).Format("2006-01-02 15:04:05")
-			utils.Logger.Infof("Scheduler cron job added: %s, next run at %s", scheduler.CronExpr, nextRun)
-		}
-	}
-
-	// Auto close
-	var closeFunc func()
-	switch settings.AutoActionType {
-	case "close_app":
-		closeFunc = utils.CloseApp
-	case "hibernate":
-		closeFunc = utils.Hibernate
-	case "shutdown":
-		closeFunc = utils.Shutdown
+		go service.GetServiceManager().SchedulerService().StartAllScheduled()
 	}
-
-	if settings.AutoActionTrigger == "scheduled" && settings.AutoActionCron != "" && closeFunc != nil {
-		entryID, err := c.AddFunc(settings.AutoActionCron, func() {
-			if scheduler.AutoClose {
-				closeFunc()
-			}
-		})
-		if err != nil {
-			utils.Logger.Errorf("Failed to add auto close cron job: %v", err)
-		} else {
-			entry := c.Entry(entryID)
-			nextRun := entry.Schedule.Next(time.Now()).Format("2006-01-02 15:04:05")
-			utils.Logger.Infof("Auto close cron job added: %s, next run at %s", settings.AutoActionCron, nextRun)
-		}
-	} else if settings.AutoActionTrigger == "scheduler_end" {
-		scheduler.CloseFunc = closeFunc
-	}
-
-	c.Start()
 }
 
 // beforeClose is called when the application is about to quit,
 // either by clicking the window close button or calling runtime.Quit.
 // Returning true will cause the application to continue, false will continue shutdown as normal.
 func (a *App) BeforeClose(ctx context.Context) (prevent bool) {
+	// Running instances may keep the app open for a while, depending on the shutdown policy
+	if service.GetServiceManager().SchedulerService().RequestShutdown(func() { runtime.Quit(ctx) }) {
+		runtime.WindowShow(ctx)
+		return true
+	}
+
+	service.GetServiceManager().CronService().Stop()
 	service.GetServiceManager().SchedulerService().StopAll()
+	service.GetServiceManager().SchedulerService().StopRunning()
 
 	// Stop file watcher
 	fileWatcher := controller.GetFileWatcher()
@@ -160,5 +160,5 @@ func broadcastUpdateMessage(msgType string, data any, message string) {
 		Data:    data,
 		Message: message,
 	}
-	wsManager.BroadcastJSON(updateMsg)
+	wsManager.Publish(utils.TopicUpdate, updateMsg)
 }
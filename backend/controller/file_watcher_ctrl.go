@@ -190,7 +190,7 @@ func (fw *FileWatcher) broadcastFileChange(instanceName, filename string) {
 	}
 	wsManager := utils.GetWSManager()
 	if wsManager != nil {
-		wsManager.BroadcastJSON(message)
+		wsManager.Publish(utils.TopicFileChange, message)
 	}
 }
 
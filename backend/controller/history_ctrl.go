@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"dacapo/backend/model"
+	"dacapo/backend/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pagination defaults and log polling interval for run history queries
+const (
+	defaultHistoryPageSize = 20
+	maxHistoryPageSize     = 100
+	runLogPollInterval     = 500 * time.Millisecond
+)
+
+// parseHistoryTime parses a time filter given as RFC3339 or a local date
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", value)
+}
+
+// GetRunHistory lists task executions with filtering and pagination
+func GetRunHistory(c *gin.Context) {
+	var req model.ReqRunHistory
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		utils.Logger.Error("Invalid request format\n", err)
+		return
+	}
+
+	since, err := parseHistoryTime(req.Since)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	until, err := parseHistoryTime(req.Until)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// A bare date as upper bound includes the whole day
+	if _, err := time.Parse("2006-01-02", req.Until); err == nil {
+		until = until.AddDate(0, 0, 1)
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = defaultHistoryPageSize
+	} else if req.PageSize > maxHistoryPageSize {
+		req.PageSize = maxHistoryPageSize
+	}
+
+	histories, total, err := model.GetRunHistories(model.RunHistoryFilter{
+		InstanceName: req.InstanceName,
+		TaskName:     req.TaskName,
+		Outcome:      req.Outcome,
+		Since:        since,
+		Until:        until,
+		Page:         req.Page,
+		PageSize:     req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusOK, model.RspRunHistory{
+			Code:    model.StatusDatabase.Code,
+			Message: model.StatusDatabase.Message,
+			Detail:  err.Error(),
+		})
+		utils.Logger.Error(err)
+		return
+	}
+
+	items := make([]model.RspRunRecord, len(histories))
+	for i := range histories {
+		items[i] = histories[i].ToRsp()
+	}
+
+	c.JSON(http.StatusOK, model.RspRunHistory{
+		Code:     model.StatusSuccess.Code,
+		Message:  model.StatusSuccess.Message,
+		Detail:   "",
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Items:    items,
+	})
+}
+
+// GetRunRecord returns a single task execution by run ID
+func GetRunRecord(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("run_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid run ID"})
+		return
+	}
+
+	var history model.RunHistory
+	if err := history.GetByID(uint(id)); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    model.StatusDatabase.Code,
+			"message": model.StatusDatabase.Message,
+			"detail":  err.Error(),
+		})
+		utils.Logger.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    model.StatusSuccess.Code,
+		"message": model.StatusSuccess.Message,
+		"detail":  "",
+		"item":    history.ToRsp(),
+	})
+}
+
+// GetRunLog returns the archived output of a run, or streams it while the run is in progress when follow=true
+func GetRunLog(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("run_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid run ID"})
+		return
+	}
+
+	var history model.RunHistory
+	if err := history.GetByID(uint(id)); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    model.StatusDatabase.Code,
+			"message": model.StatusDatabase.Message,
+			"detail":  err.Error(),
+		})
+		utils.Logger.Error(err)
+		return
+	}
+
+	file, err := os.Open(history.LogPath)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    model.StatusFile.Code,
+			"message": model.StatusFile.Message,
+			"detail":  "Run log not available",
+		})
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	if c.Query("follow") != "true" || history.Outcome != model.OutcomeRunning {
+		c.Status(http.StatusOK)
+		io.Copy(c.Writer, file)
+		return
+	}
+
+	// Stream new output until the run finishes or the client disconnects
+	buf := make([]byte, 32*1024)
+	c.Stream(func(w io.Writer) bool {
+		for {
+			n, err := file.Read(buf)
+			if n > 0 {
+				w.Write(buf[:n])
+				return true
+			}
+			if err != nil && err != io.EOF {
+				return false
+			}
+
+			if err := history.GetByID(uint(id)); err != nil || history.Outcome != model.OutcomeRunning {
+				// Flush whatever was written after the last read
+				io.Copy(w, file)
+				return false
+			}
+
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-time.After(runLogPollInterval):
+			}
+		}
+	})
+}
@@ -2,9 +2,16 @@ package controller
 
 import (
 	"dacapo/backend/model"
+	"dacapo/backend/service"
 	"dacapo/backend/utils"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,6 +34,7 @@ func CreateIstFromLocal(c *gin.Context) {
 		utils.Logger.Errorf("[%s]: %v", req.InstanceName, err)
 		return
 	}
+	Services.CronService().SyncInstance(req.InstanceName)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    model.StatusSuccess.Code,
@@ -53,6 +61,7 @@ func CreateIstFromTemplate(c *gin.Context) {
 		utils.Logger.Errorf("[%s]: %v", req.InstanceName, err)
 		return
 	}
+	Services.CronService().SyncInstance(req.InstanceName)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    model.StatusSuccess.Code,
@@ -79,6 +88,7 @@ func CreateIstFromRemote(c *gin.Context) {
 		utils.Logger.Errorf("[%s]: %v", req.InstanceName, err)
 		return
 	}
+	Services.CronService().SyncInstance(req.InstanceName)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    model.StatusSuccess.Code,
@@ -151,6 +161,25 @@ func UpdateInstance(c *gin.Context) {
 
 	// "_Base" group is for DaCapo internal settings
 	if req.Group == "_Base" {
+		if req.Task == "General" {
+			if status, err := validateScheduleItem(instanceName, req.Item, req.Value); err != nil {
+				c.JSON(http.StatusOK, gin.H{
+					"code":    status.Code,
+					"message": status.Message,
+					"detail":  err.Error(),
+				})
+				return
+			}
+		} else {
+			if status, err := validateTaskItem(instanceName, req.Task, req.Item, req.Value); err != nil {
+				c.JSON(http.StatusOK, gin.H{
+					"code":    status.Code,
+					"message": status.Message,
+					"detail":  err.Error(),
+				})
+				return
+			}
+		}
 		if req.Task == "General" && req.Item == "config_path" {
 			istInfo, err := model.GetInstanceByName(instanceName)
 			if err != nil {
@@ -187,6 +216,11 @@ func UpdateInstance(c *gin.Context) {
 			return
 		}
 
+		// Schedule changes take effect immediately
+		if req.Task == "General" && slices.Contains(scheduleItems, req.Item) {
+			Services.CronService().SyncInstance(instanceName)
+		}
+
 		if translation != nil {
 			c.JSON(http.StatusOK, gin.H{
 				"code":        model.StatusSuccess.Code,
@@ -229,6 +263,38 @@ func DeleteInstance(c *gin.Context) {
 		utils.Logger.Errorf("[%s]: %v", instanceName, err)
 		return
 	}
+	Services.CronService().RemoveInstance(instanceName)
+	Services.WebSocketService().RemoveLogBuffer(instanceName)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    model.StatusSuccess.Code,
+		"message": model.StatusSuccess.Message,
+		"detail":  "",
+	})
+}
+
+// RunTask runs a single task of an instance right away, optionally with one-off item overrides
+func RunTask(c *gin.Context) {
+	instanceName := c.Param("instance_name")
+	taskName := c.Param("task_name")
+
+	// The request body is optional
+	var req model.ReqRunTask
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		utils.Logger.Error("Invalid request format\n", err)
+		return
+	}
+
+	if status, err := Services.SchedulerService().RunTask(instanceName, taskName, req.Overrides); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    status.Code,
+			"message": status.Message,
+			"detail":  err.Error(),
+		})
+		utils.Logger.Errorf("[%s]: %v", instanceName, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    model.StatusSuccess.Code,
@@ -237,6 +303,89 @@ func DeleteInstance(c *gin.Context) {
 	})
 }
 
+// scheduleItems are the General items that change the cron schedule of an instance
+var scheduleItems = []string{"ready", "cron_expr", "timezone", "cron_jitter"}
+
+// validateScheduleItem checks scheduling related General items before they are saved
+func validateScheduleItem(instanceName, itemName string, value any) (model.Status, error) {
+	strValue, _ := value.(string)
+	switch itemName {
+	case "exec_window":
+		if _, err := model.ParseTimeWindows(strValue); err != nil {
+			return model.StatusWindow, err
+		}
+	case "depends_on":
+		graph, err := model.GetDependencyGraph()
+		if err != nil {
+			return model.StatusDatabase, err
+		}
+		deps := model.ParseDependencies(strValue)
+		for _, dep := range deps {
+			if _, ok := graph[dep]; !ok {
+				return model.StatusDependency, fmt.Errorf("instance not found: %s", dep)
+			}
+		}
+		graph[instanceName] = deps
+		if cycle := model.FindDependencyCycle(graph); cycle != nil {
+			return model.StatusDependency, fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " → "))
+		}
+	case "resources":
+		if _, err := model.ParseResources(strValue); err != nil {
+			return model.StatusResource, err
+		}
+	case "timezone":
+		if _, err := time.LoadLocation(strValue); err != nil {
+			return model.StatusCron, err
+		}
+	case "cron_expr":
+		if strValue == "" {
+			return model.StatusSuccess, nil
+		}
+		istInfo, err := model.GetInstanceByName(instanceName)
+		if err != nil {
+			return model.StatusDatabase, err
+		}
+		if _, err := service.ParseCron(strValue, istInfo.Timezone); err != nil {
+			return model.StatusCron, err
+		}
+	}
+	return model.StatusSuccess, nil
+}
+
+// validateTaskItem checks task items before they are saved
+func validateTaskItem(instanceName, taskName, itemName string, value any) (model.Status, error) {
+	strValue, _ := value.(string)
+	switch itemName {
+	case "depends_on":
+		// A task may only depend on tasks of its instance without forming a cycle
+		var istInfo model.InstanceInfo
+		if err := istInfo.GetByName(instanceName); err != nil {
+			return model.StatusDatabase, err
+		}
+
+		graph := istInfo.GetTaskGraph()
+		deps := model.ParseDependencies(strValue)
+		for _, dep := range deps {
+			if _, ok := graph[dep]; !ok {
+				return model.StatusDependency, fmt.Errorf("task not found: %s", dep)
+			}
+		}
+		graph[taskName] = deps
+		if cycle := model.FindDependencyCycle(graph); cycle != nil {
+			return model.StatusDependency, fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " → "))
+		}
+	case "exit_codes":
+		if _, err := model.ParseExitCodes(strValue); err != nil {
+			return model.StatusExitCode, err
+		}
+	case "resources":
+		if _, err := model.ParseResources(strValue); err != nil {
+			return model.StatusResource, err
+		}
+	}
+	return model.StatusSuccess, nil
+}
+
 func updateIstConf(instanceName string, req model.ReqUpdateInstance) (model.Status, error) {
 	instanceConf := model.NewIstConf()
 	if err := instanceConf.Load(instanceName); err != nil {
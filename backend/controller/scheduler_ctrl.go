@@ -2,8 +2,10 @@ package controller
 
 import (
 	"dacapo/backend/model"
+	"dacapo/backend/service"
 	"dacapo/backend/utils"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -21,7 +23,7 @@ func CreateWS(c *gin.Context) {
 		utils.Logger.Error("Failed to upgrade websocket: ", err)
 		return
 	}
-	// Create message handler function for app update responses
+	// Create message handler function for app update responses and close cancellation
 	messageHandler := func(msgType string, data map[string]any) {
 		if msgType == "update_confirm_response" {
 			if confirmed, ok := data["confirmed"].(bool); ok {
@@ -31,6 +33,8 @@ func CreateWS(c *gin.Context) {
 			if confirmed, ok := data["confirmed"].(bool); ok {
 				HandleRestartConfirmation(confirmed)
 			}
+		} else if msgType == "shutdown_cancel" {
+			Services.SchedulerService().CancelShutdown()
 		}
 	}
 
@@ -64,7 +68,15 @@ func UpdateSchedulerState(c *gin.Context) {
 		return
 	}
 
-	Services.SchedulerService().UpdateSchedulerState(req.Type, req.InstanceName)
+	if err := Services.SchedulerService().UpdateSchedulerState(req.Type, req.InstanceName); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    model.StatusState.Code,
+			"message": model.StatusState.Message,
+			"detail":  err.Error(),
+		})
+		utils.Logger.Errorf("Failed to %s instance: %v", req.Type, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    model.StatusSuccess.Code,
@@ -92,7 +104,14 @@ func SetSchedulerCron(c *gin.Context) {
 		return
 	}
 
-	Services.SchedulerService().SetSchedulerCron(req.CronExpr)
+	if err := Services.CronService().SetSchedulerCron(req.CronExpr); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    model.StatusCron.Code,
+			"message": model.StatusCron.Message,
+			"detail":  err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    model.StatusSuccess.Code,
@@ -100,3 +119,76 @@ func SetSchedulerCron(c *gin.Context) {
 		"detail":  "",
 	})
 }
+
+// Default and maximum look-ahead of upcoming scheduled runs and validated fire times
+const (
+	defaultUpcomingHours = 24
+	maxUpcomingHours     = 24 * 14
+	defaultCronNextCount = 5
+	maxCronNextCount     = 50
+)
+
+// GetUpcomingRuns lists the next fire times of all cron schedules and what each of them will run
+func GetUpcomingRuns(c *gin.Context) {
+	var req model.ReqUpcoming
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		utils.Logger.Error("Invalid request format\n", err)
+		return
+	}
+
+	if req.Hours < 1 {
+		req.Hours = defaultUpcomingHours
+	} else if req.Hours > maxUpcomingHours {
+		req.Hours = maxUpcomingHours
+	}
+
+	from := time.Now()
+	until := from.Add(time.Duration(req.Hours) * time.Hour)
+	c.JSON(http.StatusOK, model.RspUpcoming{
+		Code:    model.StatusSuccess.Code,
+		Message: model.StatusSuccess.Message,
+		Detail:  "",
+		From:    from,
+		Until:   until,
+		Runs:    Services.CronService().Upcoming(until),
+	})
+}
+
+// ValidateCron parses a cron expression like the scheduler does and returns its next fire times
+func ValidateCron(c *gin.Context) {
+	var req model.ReqCronValidate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		utils.Logger.Error("Invalid request format\n", err)
+		return
+	}
+
+	schedule, err := service.ParseCron(req.CronExpr, req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusOK, model.RspCronValidate{
+			Code:    model.StatusCron.Code,
+			Message: model.StatusCron.Message,
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if req.Count < 1 {
+		req.Count = defaultCronNextCount
+	} else if req.Count > maxCronNextCount {
+		req.Count = maxCronNextCount
+	}
+
+	next := make([]time.Time, 0, req.Count)
+	for t := schedule.Next(time.Now()); !t.IsZero() && len(next) < req.Count; t = schedule.Next(t) {
+		next = append(next, t)
+	}
+
+	c.JSON(http.StatusOK, model.RspCronValidate{
+		Code:    model.StatusSuccess.Code,
+		Message: model.StatusSuccess.Message,
+		Detail:  "",
+		Next:    next,
+	})
+}
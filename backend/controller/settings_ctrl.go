@@ -21,14 +21,25 @@ func GetSettings(c *gin.Context) {
 	}
 
 	response := model.RspSettings{
-		Language:          settings.Language,
-		RunOnStartup:      settings.RunOnStartup,
-		SchedulerCron:     settings.SchedulerCron,
-		AutoActionTrigger: settings.AutoActionTrigger,
-		AutoActionCron:    settings.AutoActionCron,
-		AutoActionType:    settings.AutoActionType,
-		MaxBgConcurrent:   settings.MaxBgConcurrent,
-		ServerChanSendKey: settings.ServerChanSendKey,
+		Language:            settings.Language,
+		RunOnStartup:        settings.RunOnStartup,
+		SchedulerCron:       settings.SchedulerCron,
+		AutoActionTrigger:   settings.AutoActionTrigger,
+		AutoActionCron:      settings.AutoActionCron,
+		AutoActionType:      settings.AutoActionType,
+		MaxBgConcurrent:     settings.MaxBgConcurrent,
+		ServerChanSendKey:   settings.ServerChanSendKey,
+		RunLogRetentionDays: settings.RunLogRetentionDays,
+		RunLogMaxFiles:      settings.RunLogMaxFiles,
+		StopGracePeriod:     settings.StopGracePeriod,
+		CatchUpWindow:       settings.CatchUpWindow,
+		SchedulerCatchUp:    settings.SchedulerCatchUp,
+		BlackoutPeriods:     settings.BlackoutPeriods,
+		QueueRebuild:        settings.QueueRebuild,
+		OrphanPolicy:        settings.OrphanPolicy,
+		ShutdownPolicy:      settings.ShutdownPolicy,
+		ShutdownTimeout:     settings.ShutdownTimeout,
+		ResourceCapacity:    settings.ResourceCapacity,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -43,6 +54,20 @@ func UpdateSettings(c *gin.Context) {
 		return
 	}
 
+	for _, cronExpr := range []*string{req.SchedulerCron, req.AutoActionCron} {
+		if cronExpr == nil {
+			continue
+		}
+		if err := service.ValidateCron(*cronExpr); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"code":    model.StatusCron.Code,
+				"message": model.StatusCron.Message,
+				"detail":  err.Error(),
+			})
+			return
+		}
+	}
+
 	if err := model.UpdateSettings(&req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to save settings",
@@ -60,6 +85,17 @@ func UpdateSettings(c *gin.Context) {
 		}
 	}
 
+	// Apply tighter retention limits to the logs archived so far
+	if req.RunLogRetentionDays != nil || req.RunLogMaxFiles != nil {
+		go service.CleanupAllRunLogs()
+	}
+
+	// Apply schedule changes without restarting
+	if req.RunOnStartup != nil || req.SchedulerCron != nil || req.AutoActionTrigger != nil ||
+		req.AutoActionCron != nil || req.AutoActionType != nil {
+		service.GetServiceManager().CronService().ReloadSettings()
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    model.StatusSuccess.Code,
 		"message": model.StatusSuccess.Message,
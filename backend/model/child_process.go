@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Orphan policies for task processes that kept running after the app stopped unexpectedly
+const (
+	OrphanAdopt  string = "adopt"  // Track the process as the running task of its instance until it exits
+	OrphanKill   string = "kill"   // Terminate the process tree
+	OrphanReport string = "report" // Only report the process and leave it alone
+)
+
+// ChildProcess records the process of a running task so that it can be found again after a crash
+type ChildProcess struct {
+	gorm.Model
+
+	InstanceName string `gorm:"index"`
+	TaskName     string
+	PID          int
+	StartTime    time.Time // Creation time of the process, tells it apart from a later process with the same PID
+}
+
+// Create inserts a record for a started task process
+func (p *ChildProcess) Create(istName, taskName string, pid int, startTime time.Time) error {
+	p.InstanceName = istName
+	p.TaskName = taskName
+	p.PID = pid
+	p.StartTime = startTime
+	return db.Create(p).Error
+}
+
+// Delete permanently removes the record once the process has exited
+func (p *ChildProcess) Delete() error {
+	return db.Unscoped().Delete(p).Error
+}
+
+// GetChildProcesses returns the records of all task processes that were running when the app last stopped
+func GetChildProcesses() ([]ChildProcess, error) {
+	var processes []ChildProcess
+	err := db.Order("id ASC").Find(&processes).Error
+	return processes, err
+}
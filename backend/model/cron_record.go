@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Catch-up policies for scheduled runs missed while the app was not running
+const (
+	CatchUpOnce string = "once" // Run once no matter how many times were missed
+	CatchUpAll  string = "all"  // Run once for every missed time
+	CatchUpSkip string = "skip" // Do not run missed times
+)
+
+// CronRecord stores the last fire time of a cron schedule
+type CronRecord struct {
+	gorm.Model
+
+	Name     string `gorm:"uniqueIndex;not null"` // Cron entry key, e.g. "scheduler" or "instance:<name>"
+	LastFire time.Time
+}
+
+// GetCronLastFire returns the last fire time of a schedule, false if it has never been recorded
+func GetCronLastFire(key string) (time.Time, bool, error) {
+	var record CronRecord
+	result := db.Where("name = ?", key).Limit(1).Find(&record)
+	if result.Error != nil {
+		return time.Time{}, false, result.Error
+	}
+	return record.LastFire, result.RowsAffected > 0, nil
+}
+
+// SaveCronLastFire creates or updates the last fire time of a schedule
+func SaveCronLastFire(key string, lastFire time.Time) error {
+	record := CronRecord{Name: key, LastFire: lastFire}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_fire", "updated_at"}),
+	}).Create(&record).Error
+}
+
+// DeleteCronRecord permanently removes the record of a schedule
+func DeleteCronRecord(key string) error {
+	return db.Unscoped().Where("name = ?", key).Delete(&CronRecord{}).Error
+}
@@ -21,6 +21,10 @@ func InitDB() {
 		&TemplateInfo{},
 		&InstanceInfo{},
 		&TaskInfo{},
+		&RunHistory{},
+		&CronRecord{},
+		&InstanceState{},
+		&ChildProcess{},
 	)
 	if err != nil {
 		utils.Logger.Fatal("Failed to migrate database: ", err)
@@ -31,6 +35,13 @@ func InitDB() {
 		utils.Logger.Warnf("Failed to migrate order: %v", err)
 	}
 
+	// Runs still marked as running were cut short by the last shutdown
+	if count, err := MarkInterruptedRuns(); err != nil {
+		utils.Logger.Warnf("Failed to mark interrupted runs: %v", err)
+	} else if count > 0 {
+		utils.Logger.Warnf("Marked %d interrupted runs", count)
+	}
+
 	utils.Logger.Info("Database initialized")
 }
 
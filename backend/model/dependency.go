@@ -0,0 +1,93 @@
+package model
+
+import (
+	"slices"
+	"strings"
+)
+
+// Policies for an instance whose dependency failed in the same scheduler run
+const (
+	DependencySkip string = "skip" // Do not run the instance
+	DependencyRun  string = "run"  // Run the instance once the dependency has finished
+)
+
+// ParseDependencies parses a comma separated list of instance names
+func ParseDependencies(spec string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// GetDependencyGraph returns the declared dependencies of every instance
+func GetDependencyGraph() (map[string][]string, error) {
+	var instances []InstanceInfo
+	if err := GetAllInstances(&instances); err != nil {
+		return nil, err
+	}
+
+	graph := make(map[string][]string, len(instances))
+	for _, ist := range instances {
+		graph[ist.Name] = ParseDependencies(ist.DependsOn)
+	}
+	return graph, nil
+}
+
+// GetTaskGraph returns the declared dependencies of every task of the instance
+func (i *InstanceInfo) GetTaskGraph() map[string][]string {
+	graph := make(map[string][]string, len(i.Tasks))
+	for _, task := range i.Tasks {
+		graph[task.Name] = ParseDependencies(task.DependsOn)
+	}
+	return graph
+}
+
+// FindDependencyCycle returns one cycle of the graph such as [A B A], or nil if there is none
+func FindDependencyCycle(graph map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(graph))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range graph[name] {
+			switch state[dep] {
+			case visiting:
+				start := slices.Index(path, dep)
+				return append(slices.Clone(path[start:]), dep)
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	// Visit in a stable order so that the reported cycle does not change between calls
+	names := make([]string, 0, len(graph))
+	for name := range graph {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDependencies(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{spec: "", want: []string{}},
+		{spec: "game-a", want: []string{"game-a"}},
+		{spec: " game-a , ,game-b, game-a ", want: []string{"game-a", "game-b"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseDependencies(tt.spec); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseDependencies(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestFindDependencyCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph map[string][]string
+		want  []string
+	}{
+		{
+			name:  "empty",
+			graph: map[string][]string{},
+		},
+		{
+			name: "chain",
+			graph: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {},
+			},
+		},
+		{
+			name: "shared dependency is no cycle",
+			graph: map[string][]string{
+				"a": {"b", "c"},
+				"b": {"d"},
+				"c": {"d"},
+				"d": {},
+			},
+		},
+		{
+			name: "unknown dependency",
+			graph: map[string][]string{
+				"a": {"missing"},
+			},
+		},
+		{
+			name:  "self dependency",
+			graph: map[string][]string{"a": {"a"}},
+			want:  []string{"a", "a"},
+		},
+		{
+			name: "two instances",
+			graph: map[string][]string{
+				"a": {"b"},
+				"b": {"a"},
+			},
+			want: []string{"a", "b", "a"},
+		},
+		{
+			name: "cycle behind a chain",
+			graph: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {"d"},
+				"d": {"b"},
+			},
+			want: []string{"b", "c", "d", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindDependencyCycle(tt.graph); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDependencyCycle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
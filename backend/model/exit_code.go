@@ -0,0 +1,89 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Outcomes that templates can assign to exit codes
+const (
+	ExitSuccess string = "success" // Counted as a successful run
+	ExitFailure string = "failure" // Counted as a failed run
+	ExitSkipped string = "skipped" // Nothing to do, neither success nor failure
+	ExitRetry   string = "retry"   // Run the task again after the retry delay
+)
+
+var exitOutcomes = []string{ExitSuccess, ExitFailure, ExitSkipped, ExitRetry}
+
+// ParseExitCodes parses an exit code mapping such as "0:success, 3:skipped, 75:retry"
+func ParseExitCodes(spec string) (map[int]string, error) {
+	mapping := make(map[int]string)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		codeStr, outcome, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid exit code mapping %q, expected code:outcome", part)
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(codeStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exit code %q", codeStr)
+		}
+		outcome = strings.ToLower(strings.TrimSpace(outcome))
+		if !slices.Contains(exitOutcomes, outcome) {
+			return nil, fmt.Errorf("invalid outcome %q for exit code %d, expected one of %s", outcome, code, strings.Join(exitOutcomes, ", "))
+		}
+		mapping[code] = outcome
+	}
+	return mapping, nil
+}
+
+// FormatExitCodes converts an exit code mapping from a template into its stored form
+// Templates may write the mapping as a string or as a map from exit code to outcome
+func FormatExitCodes(value any) (string, bool) {
+	pairs := make(map[string]any)
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case map[string]any:
+		pairs = v
+	case map[any]any:
+		for code, outcome := range v {
+			pairs[fmt.Sprint(code)] = outcome
+		}
+	default:
+		return "", false
+	}
+
+	codes := make([]int, 0, len(pairs))
+	outcomes := make(map[int]string, len(pairs))
+	for codeStr, outcome := range pairs {
+		code, err := strconv.Atoi(codeStr)
+		if err != nil {
+			return "", false
+		}
+		codes = append(codes, code)
+		outcomes[code] = fmt.Sprint(outcome)
+	}
+	slices.Sort(codes)
+
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		parts = append(parts, fmt.Sprintf("%d:%s", code, outcomes[code]))
+	}
+	return strings.Join(parts, ", "), true
+}
+
+// GetExitOutcome returns the outcome the task declares for an exit code, empty if it declares none
+func (t *TaskInfo) GetExitOutcome(code int) string {
+	mapping, err := ParseExitCodes(t.ExitCodes)
+	if err != nil {
+		return ""
+	}
+	return mapping[code]
+}
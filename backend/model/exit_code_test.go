@@ -0,0 +1,85 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExitCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[int]string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			spec: "",
+			want: map[int]string{},
+		},
+		{
+			name: "several codes",
+			spec: "0:success, 3:skipped, 75:retry",
+			want: map[int]string{0: ExitSuccess, 3: ExitSkipped, 75: ExitRetry},
+		},
+		{
+			name: "spaces, case and negative codes",
+			spec: " -1 : Failure ,, 2:SUCCESS ",
+			want: map[int]string{-1: ExitFailure, 2: ExitSuccess},
+		},
+		{
+			name: "later mapping wins",
+			spec: "1:failure, 1:retry",
+			want: map[int]string{1: ExitRetry},
+		},
+		{name: "missing outcome", spec: "3", wantErr: true},
+		{name: "code is not a number", spec: "x:success", wantErr: true},
+		{name: "unknown outcome", spec: "3:ignored", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExitCodes(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseExitCodes(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseExitCodes(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  any
+		want   string
+		wantOK bool
+	}{
+		{name: "string", value: "3:skipped", want: "3:skipped", wantOK: true},
+		{
+			name:   "json map is sorted by code",
+			value:  map[string]any{"75": "retry", "3": "skipped"},
+			want:   "3:skipped, 75:retry",
+			wantOK: true,
+		},
+		{
+			name:   "yaml map",
+			value:  map[any]any{75: "retry", 0: "success"},
+			want:   "0:success, 75:retry",
+			wantOK: true,
+		},
+		{name: "code is not a number", value: map[string]any{"x": "retry"}, wantOK: false},
+		{name: "unsupported type", value: 3, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FormatExitCodes(tt.value)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("FormatExitCodes(%v) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
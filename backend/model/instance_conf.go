@@ -218,3 +218,33 @@ func (i *InstanceConf) SetValue(menuName, taskName, groupName, itemName string,
 
 	return nil
 }
+
+// FindTask returns the name of the menu that contains the task
+func (i *InstanceConf) FindTask(taskName string) (string, bool) {
+	for pair := i.OM.Oldest(); pair != nil; pair = pair.Next() {
+		if _, exists := pair.Value.Get(taskName); exists {
+			return pair.Key, true
+		}
+	}
+	return "", false
+}
+
+// Override changes an existing item in memory only, the instance file is left unchanged
+func (i *InstanceConf) Override(menuName, taskName, groupName, itemName string, value any) error {
+	if i.GetValue(menuName, taskName, groupName, itemName) == nil {
+		return fmt.Errorf("item %s.%s not exists in task %s", groupName, itemName, taskName)
+	}
+	taskConf, _ := i.OM.Value(menuName).Get(taskName)
+	groupConf, _ := taskConf.Get(groupName)
+	groupConf.Set(itemName, value)
+	return nil
+}
+
+// SaveAs writes the configuration to another file
+func (i *InstanceConf) SaveAs(filePath string) error {
+	jsonData, err := json.MarshalIndent(i.OM, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, jsonData, 0644)
+}
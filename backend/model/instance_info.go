@@ -1,6 +1,7 @@
 package model
 
 import (
+	"dacapo/backend/utils"
 	"os"
 	"path/filepath"
 	"slices"
@@ -15,13 +16,79 @@ type TaskInfo struct {
 	gorm.Model
 	InstanceID uint `gorm:"index"`
 
-	Name             string
-	Active           *bool `gorm:"default:true"`
-	ActiveDisabled   bool
-	Priority         uint
-	PriorityDisabled bool
-	Command          string
-	CommandDisabled  bool
+	Name              string
+	Active            *bool `gorm:"default:true"`
+	ActiveDisabled    bool
+	Priority          uint
+	PriorityDisabled  bool
+	Command           string
+	CommandDisabled   bool
+	Timeout           uint // Minutes, 0 means use the instance default
+	TimeoutDisabled   bool
+	Resources         string // Named resources held while the task runs, e.g. "gpu"
+	ResourcesDisabled bool
+
+	// Ordering within the instance
+	DependsOn           string // Comma separated tasks that must succeed before this one
+	DependsOnDisabled   bool
+	RunIfFailed         bool // Cleanup task that only runs after another task failed
+	RunIfFailedDisabled bool
+	Always              bool // Cleanup task that runs whether or not another task failed
+	AlwaysDisabled      bool
+
+	// Retry policy for failed runs
+	MaxRetries           uint
+	MaxRetriesDisabled   bool
+	RetryDelay           uint // Seconds before the first retry
+	RetryDelayDisabled   bool
+	RetryBackoff         string `gorm:"default:'fixed'"` // fixed, linear or exponential
+	RetryBackoffDisabled bool
+	OnFailure            string `gorm:"default:'default'"` // Failure policy, default uses the instance policy
+	OnFailureDisabled    bool
+	ExitCodes            string // Outcomes of exit codes, e.g. "3:skipped, 75:retry"
+	ExitCodesDisabled    bool
+}
+
+// Retry backoff strategies
+const (
+	BackoffFixed       string = "fixed"
+	BackoffLinear      string = "linear"
+	BackoffExponential string = "exponential"
+)
+
+// GetRetryDelay returns the delay before the given retry, counting from 1
+func (t *TaskInfo) GetRetryDelay(retry int) time.Duration {
+	delay := time.Duration(t.RetryDelay) * time.Second
+	switch t.RetryBackoff {
+	case BackoffLinear:
+		return delay * time.Duration(retry)
+	case BackoffExponential:
+		return delay << (retry - 1)
+	default:
+		return delay
+	}
+}
+
+// Failure policies of a task
+const (
+	FailureDefault           string = "default"             // Use the policy of the instance
+	FailureStopInstance      string = "stop_instance"       // Retry, then stop the instance
+	FailureContinue          string = "continue"            // Continue with the next task without retrying
+	FailureRetryThenContinue string = "retry_then_continue" // Retry, then continue with the next task
+)
+
+// IsCleanup reports whether the task still runs after another task of the instance failed
+func (t *TaskInfo) IsCleanup() bool {
+	return t.Always || t.RunIfFailed
+}
+
+// CanRun reports whether the task runs in a run where a task has failed or not, and where the
+// instance was halted by a failure or not
+func (t *TaskInfo) CanRun(failed, halted bool) bool {
+	if halted {
+		return t.IsCleanup()
+	}
+	return failed || t.Always || !t.RunIfFailed
 }
 
 // InstanceInfo stores built-in DaCapo settings that are independent of specific templates
@@ -41,11 +108,22 @@ type InstanceInfo struct {
 	WorkDirDisabled    bool
 	Background         bool
 	BackgroundDisabled bool
+	Resources          string // Named resources held during the whole run, e.g. "emulator-5555"
+	ResourcesDisabled  bool
+	DependsOn          string // Comma separated instances that must finish first in a scheduler run
+	DependencyPolicy   string `gorm:"default:'skip'"` // What happens when a dependency fails
 	ConfigPath         string
 	ConfigPathDisabled bool
 	LogPath            string
 	LogPathDisabled    bool
 	CronExpr           string
+	Timezone           string // IANA timezone of the cron expression and execution windows, empty for local time
+	CronJitter         uint   // Random delay of scheduled starts in minutes
+	CatchUp            string `gorm:"default:'once'"` // Policy for scheduled runs missed while the app was closed
+	ExecWindow         string // Allowed execution time windows, empty means any time
+	WindowPolicy       string `gorm:"default:'finish'"` // What happens to a running task when the window closes
+	TaskTimeout        uint   // Default task timeout in minutes, 0 means no limit
+	OnFailure          string `gorm:"default:'stop_instance'"` // Default failure policy of the tasks
 
 	// auto-generated during instance creation, read-only
 	RepoURL         string
@@ -100,6 +178,10 @@ func DeleteIstInfoByName(name string) error {
 		}
 	}
 
+	if err := DeleteInstanceState(name); err != nil {
+		return err
+	}
+
 	// Delete the instance from database
 	err := instance.Delete()
 	return err
@@ -163,6 +245,22 @@ func (i *InstanceInfo) Create(istName, tplName string, tpl *TemplateConf) error
 					i.BackgroundDisabled = item.Disabled
 				}
 			},
+			"resources": func(item ItemConf) {
+				if v, ok := item.Value.(string); ok {
+					i.Resources = v
+					i.ResourcesDisabled = item.Disabled
+				}
+			},
+			"depends_on": func(item ItemConf) {
+				if v, ok := item.Value.(string); ok {
+					i.DependsOn = v
+				}
+			},
+			"dependency_policy": func(item ItemConf) {
+				if v, ok := item.Value.(string); ok {
+					i.DependencyPolicy = v
+				}
+			},
 			"config_path": func(item ItemConf) {
 				if v, ok := item.Value.(string); ok {
 					i.ConfigPath = v
@@ -180,6 +278,41 @@ func (i *InstanceInfo) Create(istName, tplName string, tpl *TemplateConf) error
 					i.CronExpr = v
 				}
 			},
+			"timezone": func(item ItemConf) {
+				if v, ok := item.Value.(string); ok {
+					i.Timezone = v
+				}
+			},
+			"cron_jitter": func(item ItemConf) {
+				if v, ok := uintValue(item.Value); ok {
+					i.CronJitter = v
+				}
+			},
+			"catch_up": func(item ItemConf) {
+				if v, ok := item.Value.(string); ok {
+					i.CatchUp = v
+				}
+			},
+			"exec_window": func(item ItemConf) {
+				if v, ok := item.Value.(string); ok {
+					i.ExecWindow = v
+				}
+			},
+			"window_policy": func(item ItemConf) {
+				if v, ok := item.Value.(string); ok {
+					i.WindowPolicy = v
+				}
+			},
+			"on_failure": func(item ItemConf) {
+				if v, ok := item.Value.(string); ok {
+					i.OnFailure = v
+				}
+			},
+			"task_timeout": func(item ItemConf) {
+				if v, ok := uintValue(item.Value); ok {
+					i.TaskTimeout = v
+				}
+			},
 		},
 		"Update": {
 			"branch": func(item ItemConf) {
@@ -359,6 +492,66 @@ func (i *InstanceInfo) CreateTask(taskName string, taskConf *orderedmap.OrderedM
 					task.CommandDisabled = cmdConf.Disabled
 				}
 			}
+			if timeoutConf, exists := baseGroup.Get("timeout"); exists {
+				if v, ok := uintValue(timeoutConf.Value); ok {
+					task.Timeout = v
+					task.TimeoutDisabled = timeoutConf.Disabled
+				}
+			}
+			if resourcesConf, exists := baseGroup.Get("resources"); exists {
+				if v, ok := resourcesConf.Value.(string); ok {
+					task.Resources = v
+					task.ResourcesDisabled = resourcesConf.Disabled
+				}
+			}
+			if dependsConf, exists := baseGroup.Get("depends_on"); exists {
+				if v, ok := dependsConf.Value.(string); ok {
+					task.DependsOn = v
+					task.DependsOnDisabled = dependsConf.Disabled
+				}
+			}
+			if runIfFailedConf, exists := baseGroup.Get("run_if_failed"); exists {
+				if v, ok := runIfFailedConf.Value.(bool); ok {
+					task.RunIfFailed = v
+					task.RunIfFailedDisabled = runIfFailedConf.Disabled
+				}
+			}
+			if alwaysConf, exists := baseGroup.Get("always"); exists {
+				if v, ok := alwaysConf.Value.(bool); ok {
+					task.Always = v
+					task.AlwaysDisabled = alwaysConf.Disabled
+				}
+			}
+			if retriesConf, exists := baseGroup.Get("max_retries"); exists {
+				if v, ok := uintValue(retriesConf.Value); ok {
+					task.MaxRetries = v
+					task.MaxRetriesDisabled = retriesConf.Disabled
+				}
+			}
+			if delayConf, exists := baseGroup.Get("retry_delay"); exists {
+				if v, ok := uintValue(delayConf.Value); ok {
+					task.RetryDelay = v
+					task.RetryDelayDisabled = delayConf.Disabled
+				}
+			}
+			if backoffConf, exists := baseGroup.Get("retry_backoff"); exists {
+				if v, ok := backoffConf.Value.(string); ok {
+					task.RetryBackoff = v
+					task.RetryBackoffDisabled = backoffConf.Disabled
+				}
+			}
+			if failureConf, exists := baseGroup.Get("on_failure"); exists {
+				if v, ok := failureConf.Value.(string); ok {
+					task.OnFailure = v
+					task.OnFailureDisabled = failureConf.Disabled
+				}
+			}
+			if exitCodesConf, exists := baseGroup.Get("exit_codes"); exists {
+				if v, ok := FormatExitCodes(exitCodesConf.Value); ok {
+					task.ExitCodes = v
+					task.ExitCodesDisabled = exitCodesConf.Disabled
+				}
+			}
 		}
 	}
 
@@ -373,3 +566,53 @@ func (i *InstanceInfo) CreateTask(taskName string, taskConf *orderedmap.OrderedM
 	i.Tasks = append(i.Tasks, task)
 	return nil
 }
+
+// uintValue converts a numeric template value to uint, negative values are rejected
+func uintValue(value any) (uint, bool) {
+	switch v := value.(type) {
+	case uint:
+		return v, true
+	case int:
+		if v >= 0 {
+			return uint(v), true
+		}
+	case float64:
+		if v >= 0 {
+			return uint(v), true
+		}
+	}
+	return 0, false
+}
+
+// GetTaskTimeout returns the effective timeout of a task, 0 means no limit
+func (i *InstanceInfo) GetTaskTimeout(task *TaskInfo) time.Duration {
+	minutes := task.Timeout
+	if minutes == 0 {
+		minutes = i.TaskTimeout
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GetFailurePolicy returns the effective failure policy of a task
+func (i *InstanceInfo) GetFailurePolicy(task *TaskInfo) string {
+	if task != nil && task.OnFailure != "" && task.OnFailure != FailureDefault {
+		return task.OnFailure
+	}
+	if i.OnFailure == "" {
+		return FailureStopInstance
+	}
+	return i.OnFailure
+}
+
+// GetLocation returns the timezone of the instance, local time if unset or invalid
+func (i *InstanceInfo) GetLocation() *time.Location {
+	if i.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(i.Timezone)
+	if err != nil {
+		utils.Logger.Warnf("[%s]: Invalid timezone %s, using local time", i.Name, i.Timezone)
+		return time.Local
+	}
+	return loc
+}
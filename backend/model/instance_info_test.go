@@ -0,0 +1,45 @@
+package model
+
+import "testing"
+
+func TestGetFailurePolicy(t *testing.T) {
+	ist := &InstanceInfo{}
+	// Instances created before failure policies existed stop on failure
+	if got := ist.GetFailurePolicy(nil); got != FailureStopInstance {
+		t.Errorf("GetFailurePolicy() of an unset instance policy = %s, want %s", got, FailureStopInstance)
+	}
+
+	ist.OnFailure = FailureRetryThenContinue
+	if got := ist.GetFailurePolicy(&TaskInfo{OnFailure: FailureDefault}); got != FailureRetryThenContinue {
+		t.Errorf("GetFailurePolicy() of a default task = %s, want the instance policy %s", got, FailureRetryThenContinue)
+	}
+	if got := ist.GetFailurePolicy(&TaskInfo{}); got != FailureRetryThenContinue {
+		t.Errorf("GetFailurePolicy() of a task without policy = %s, want the instance policy %s", got, FailureRetryThenContinue)
+	}
+	if got := ist.GetFailurePolicy(&TaskInfo{OnFailure: FailureContinue}); got != FailureContinue {
+		t.Errorf("GetFailurePolicy() of a continuing task = %s, want %s", got, FailureContinue)
+	}
+}
+
+func TestTaskCanRun(t *testing.T) {
+	normal := &TaskInfo{Name: "daily"}
+	onFailure := &TaskInfo{Name: "report", RunIfFailed: true}
+	always := &TaskInfo{Name: "close_game", Always: true}
+
+	// Nothing failed
+	if !normal.CanRun(false, false) || onFailure.CanRun(false, false) || !always.CanRun(false, false) {
+		t.Error("without failures only the normal and always tasks should run")
+	}
+	// A task failed, but its policy continued with the next task
+	if !normal.CanRun(true, false) || !onFailure.CanRun(true, false) || !always.CanRun(true, false) {
+		t.Error("after a continued failure all tasks should run")
+	}
+	// A task failed and stopped the instance, only the cleanup tasks are left
+	if normal.CanRun(true, true) || !onFailure.CanRun(true, true) || !always.CanRun(true, true) {
+		t.Error("after a halting failure only the cleanup tasks should run")
+	}
+
+	if normal.IsCleanup() || !onFailure.IsCleanup() || !always.IsCleanup() {
+		t.Error("IsCleanup() should only hold for tasks that run after failures")
+	}
+}
@@ -32,12 +32,17 @@ type ReqUpdateInstance struct {
 	Value any    `json:"value" binding:"required"`
 }
 
+// ReqRunTask represents a request to run a single task, overrides map group names to item values
+type ReqRunTask struct {
+	Overrides map[string]map[string]any `json:"overrides"`
+}
+
 // ReqUpdateQueue represents a request to update the homepage task queue
 type ReqUpdateQueue struct {
 	Queues map[string]TaskQueue `json:"queues" binding:"required"`
 }
 
-// ReqSchedulerState represents a request to start or stop task execution (type: "start" / "stop")
+// ReqSchedulerState represents a request to control task execution (type: "start" / "stop" / "pause" / "resume")
 type ReqSchedulerState struct {
 	Type         string `json:"type" binding:"required"`
 	InstanceName string `json:"instance_name"`
@@ -54,12 +59,46 @@ type ReqUpdateOrder struct {
 
 // Settings related requests
 type ReqUpdateSettings struct {
-	Language          string  `json:"language"`
-	RunOnStartup      *bool   `json:"runOnStartup"`
-	SchedulerCron     *string `json:"schedulerCron"`
-	AutoActionTrigger *string `json:"autoActionTrigger"`
-	AutoActionCron    *string `json:"autoActionCron"`
-	AutoActionType    *string `json:"autoActionType"`
-	MaxBgConcurrent   *int    `json:"maxBgConcurrent"`
-	ServerChanSendKey *string `json:"serverChanSendKey"`
+	Language            string  `json:"language"`
+	RunOnStartup        *bool   `json:"runOnStartup"`
+	SchedulerCron       *string `json:"schedulerCron"`
+	AutoActionTrigger   *string `json:"autoActionTrigger"`
+	AutoActionCron      *string `json:"autoActionCron"`
+	AutoActionType      *string `json:"autoActionType"`
+	MaxBgConcurrent     *int    `json:"maxBgConcurrent"`
+	ServerChanSendKey   *string `json:"serverChanSendKey"`
+	RunLogRetentionDays *int    `json:"runLogRetentionDays"`
+	RunLogMaxFiles      *int    `json:"runLogMaxFiles"`
+	StopGracePeriod     *int    `json:"stopGracePeriod"`
+	CatchUpWindow       *int    `json:"catchUpWindow"`
+	SchedulerCatchUp    *string `json:"schedulerCatchUp"`
+	BlackoutPeriods     *string `json:"blackoutPeriods"`
+	QueueRebuild        *string `json:"queueRebuild"`
+	OrphanPolicy        *string `json:"orphanPolicy"`
+	ShutdownPolicy      *string `json:"shutdownPolicy"`
+	ShutdownTimeout     *int    `json:"shutdownTimeout"`
+	ResourceCapacity    *string `json:"resourceCapacity"`
+}
+
+// ReqRunHistory represents the query parameters for listing run history
+type ReqRunHistory struct {
+	InstanceName string `form:"instance_name"`
+	TaskName     string `form:"task_name"`
+	Outcome      string `form:"outcome"`
+	Since        string `form:"since"` // RFC3339 or YYYY-MM-DD
+	Until        string `form:"until"` // RFC3339 or YYYY-MM-DD
+	Page         int    `form:"page"`
+	PageSize     int    `form:"page_size"`
+}
+
+// ReqUpcoming represents the query parameters for listing upcoming scheduled runs
+type ReqUpcoming struct {
+	Hours int `form:"hours"`
+}
+
+// ReqCronValidate represents a cron expression to validate
+type ReqCronValidate struct {
+	CronExpr string `json:"cron_expr" binding:"required"`
+	Timezone string `json:"timezone"` // IANA name, empty for local time
+	Count    int    `json:"count"`    // Number of fire times to return
 }
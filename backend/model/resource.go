@@ -0,0 +1,57 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Implicit resources that keep the former foreground/background behavior
+const (
+	ResourceForeground string = "foreground" // Held by every non-background instance, capacity 1
+	ResourceBackground string = "background" // Held by background instances when MaxBgConcurrent is set
+)
+
+// Resource is a named lock with a capacity, each holder takes one unit
+// A capacity listed in the resource capacity setting takes precedence over the declared one
+type Resource struct {
+	Name     string
+	Capacity int
+}
+
+// ParseResources parses a comma separated list such as "emulator-5555:1, gpu:2", the capacity defaults to 1
+func ParseResources(spec string) ([]Resource, error) {
+	resources := make([]Resource, 0)
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		resource := Resource{Name: part, Capacity: 1}
+		if idx := strings.LastIndex(part, ":"); idx >= 0 {
+			capacity, err := strconv.Atoi(strings.TrimSpace(part[idx+1:]))
+			if err != nil || capacity < 1 {
+				return nil, fmt.Errorf("invalid capacity in resource: %s", part)
+			}
+			resource.Name = strings.TrimSpace(part[:idx])
+			resource.Capacity = capacity
+		}
+		if resource.Name == "" {
+			return nil, fmt.Errorf("empty resource name: %s", part)
+		}
+		if seen[resource.Name] {
+			continue
+		}
+		seen[resource.Name] = true
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// IsValidResources reports whether a resource list can be parsed
+func IsValidResources(spec string) bool {
+	_, err := ParseResources(spec)
+	return err == nil
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []Resource
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			spec: " , ",
+			want: []Resource{},
+		},
+		{
+			name: "default capacity",
+			spec: "emulator-5555",
+			want: []Resource{{Name: "emulator-5555", Capacity: 1}},
+		},
+		{
+			name: "capacities and spaces",
+			spec: " gpu : 2, account-a:1 ",
+			want: []Resource{{Name: "gpu", Capacity: 2}, {Name: "account-a", Capacity: 1}},
+		},
+		{
+			name: "duplicates keep the first declaration",
+			spec: "gpu:2, gpu:3",
+			want: []Resource{{Name: "gpu", Capacity: 2}},
+		},
+		{
+			name: "colon in the name",
+			spec: "127.0.0.1:5555:1",
+			want: []Resource{{Name: "127.0.0.1:5555", Capacity: 1}},
+		},
+		{name: "zero capacity", spec: "gpu:0", wantErr: true},
+		{name: "capacity is not a number", spec: "gpu:two", wantErr: true},
+		{name: "empty name", spec: ":2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResources(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResources(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseResources(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
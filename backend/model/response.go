@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Status struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -8,13 +10,20 @@ type Status struct {
 var (
 	StatusSuccess = Status{Code: 0, Message: "Success"}
 
-	StatusFile      = Status{Code: 1001, Message: "File operation failed"}
-	StatusDatabase  = Status{Code: 1002, Message: "Database error"}
-	StatusDuplicate = Status{Code: 1003, Message: "Instance name already exists"}
-	StatusGit       = Status{Code: 1004, Message: "Git repository operation failed"}
-	StatusPython    = Status{Code: 1005, Message: "Python environment operation failed"}
-	StatusNetwork   = Status{Code: 1006, Message: "Network operation failed"}
-	StatusBusy      = Status{Code: 1007, Message: "Instance is busy"}
+	StatusFile       = Status{Code: 1001, Message: "File operation failed"}
+	StatusDatabase   = Status{Code: 1002, Message: "Database error"}
+	StatusDuplicate  = Status{Code: 1003, Message: "Instance name already exists"}
+	StatusGit        = Status{Code: 1004, Message: "Git repository operation failed"}
+	StatusPython     = Status{Code: 1005, Message: "Python environment operation failed"}
+	StatusNetwork    = Status{Code: 1006, Message: "Network operation failed"}
+	StatusBusy       = Status{Code: 1007, Message: "Instance is busy"}
+	StatusCron       = Status{Code: 1008, Message: "Invalid cron expression"}
+	StatusWindow     = Status{Code: 1009, Message: "Invalid time window"}
+	StatusDependency = Status{Code: 1010, Message: "Invalid instance dependency"}
+	StatusExitCode   = Status{Code: 1011, Message: "Invalid exit code mapping"}
+	StatusState      = Status{Code: 1012, Message: "Invalid instance state"}
+	StatusOverride   = Status{Code: 1013, Message: "Invalid task override"}
+	StatusResource   = Status{Code: 1014, Message: "Invalid resource list"}
 )
 
 type RspGetInstance struct {
@@ -62,6 +71,15 @@ type RspSchedulerState struct {
 	State        string `json:"state"`
 }
 
+// RspShutdown reports a close of the app that waits for running instances
+type RspShutdown struct {
+	Type      string   `json:"type"`
+	State     string   `json:"state"` // "waiting" while the countdown runs, then "closing" or "cancelled"
+	Policy    string   `json:"policy"`
+	Remaining int      `json:"remaining"` // Seconds until running instances are stopped, -1 without limit
+	Instances []string `json:"instances"` // Instances that are still waited for
+}
+
 type RspFileChange struct {
 	Type         string `json:"type"`
 	InstanceName string `json:"instance_name"`
@@ -71,14 +89,25 @@ type RspFileChange struct {
 
 // Settings response
 type RspSettings struct {
-	Language          string `json:"language"`
-	RunOnStartup      bool   `json:"runOnStartup"`
-	SchedulerCron     string `json:"schedulerCron"`
-	AutoActionTrigger string `json:"autoActionTrigger"`
-	AutoActionCron    string `json:"autoActionCron"`
-	AutoActionType    string `json:"autoActionType"`
-	MaxBgConcurrent   int    `json:"maxBgConcurrent"`
-	ServerChanSendKey string `json:"serverChanSendKey"`
+	Language            string `json:"language"`
+	RunOnStartup        bool   `json:"runOnStartup"`
+	SchedulerCron       string `json:"schedulerCron"`
+	AutoActionTrigger   string `json:"autoActionTrigger"`
+	AutoActionCron      string `json:"autoActionCron"`
+	AutoActionType      string `json:"autoActionType"`
+	MaxBgConcurrent     int    `json:"maxBgConcurrent"`
+	ServerChanSendKey   string `json:"serverChanSendKey"`
+	RunLogRetentionDays int    `json:"runLogRetentionDays"`
+	RunLogMaxFiles      int    `json:"runLogMaxFiles"`
+	StopGracePeriod     int    `json:"stopGracePeriod"`
+	CatchUpWindow       int    `json:"catchUpWindow"`
+	SchedulerCatchUp    string `json:"schedulerCatchUp"`
+	BlackoutPeriods     string `json:"blackoutPeriods"`
+	QueueRebuild        string `json:"queueRebuild"`
+	OrphanPolicy        string `json:"orphanPolicy"`
+	ShutdownPolicy      string `json:"shutdownPolicy"`
+	ShutdownTimeout     int    `json:"shutdownTimeout"`
+	ResourceCapacity    string `json:"resourceCapacity"`
 }
 
 // WebSocket message for app updates
@@ -87,3 +116,71 @@ type RspUpdateMessage struct {
 	Data    any    `json:"data"`
 	Message string `json:"message,omitempty"`
 }
+
+// Run history record
+type RspRunRecord struct {
+	ID           uint      `json:"id"`
+	InstanceName string    `json:"instance_name"`
+	TaskName     string    `json:"task_name"`
+	Attempt      int       `json:"attempt"`
+	Command      string    `json:"command"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	ExitCode     int       `json:"exit_code"`
+	Outcome      string    `json:"outcome"`
+	StderrTail   string    `json:"stderr_tail"`
+	HasLog       bool      `json:"has_log"`
+}
+
+type RspRunHistory struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Items    []RspRunRecord `json:"items"`
+}
+
+// Recent log lines of an instance, replayed to WebSocket clients
+type RspLogHistory struct {
+	Type         string   `json:"type"`
+	InstanceName string   `json:"instance_name"`
+	Lines        []string `json:"lines"`
+}
+
+// Instance and tasks started by a scheduled run
+type RspUpcomingInstance struct {
+	Name       string   `json:"name"`
+	Background bool     `json:"background"`
+	Tasks      []string `json:"tasks"`
+}
+
+// A single upcoming fire time of a schedule
+type RspUpcomingRun struct {
+	Time      time.Time             `json:"time"`
+	Schedule  string                `json:"schedule"` // "instance", "scheduler" or "auto_action"
+	CronExpr  string                `json:"cron_expr"`
+	Action    string                `json:"action,omitempty"` // Auto action type
+	Jitter    uint                  `json:"jitter,omitempty"` // Minutes the start may be delayed randomly
+	Instances []RspUpcomingInstance `json:"instances"`
+}
+
+type RspUpcoming struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+
+	From  time.Time        `json:"from"`
+	Until time.Time        `json:"until"`
+	Runs  []RspUpcomingRun `json:"runs"`
+}
+
+type RspCronValidate struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+
+	Next []time.Time `json:"next"`
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Run outcome constants
+const (
+	OutcomeRunning     string = "running"
+	OutcomeSuccess     string = "success"
+	OutcomeFailed      string = "failed"
+	OutcomeStopped     string = "stopped"
+	OutcomeTimeout     string = "timeout"
+	OutcomeSkipped     string = "skipped"
+	OutcomeInterrupted string = "interrupted" // The app stopped while the task was running
+	OutcomePartial     string = "partial"     // The run completed, but tasks whose failure policy continues failed
+)
+
+// RunHistory records a single task execution started by the scheduler
+type RunHistory struct {
+	gorm.Model
+
+	InstanceName string `gorm:"index"`
+	TaskName     string `gorm:"index"`
+	Attempt      int    `gorm:"default:1"` // 1 for the first run, increased on each retry
+	Command      string
+	StartTime    time.Time `gorm:"index"`
+	EndTime      time.Time
+	ExitCode     int
+	Outcome      string `gorm:"index"`
+	StderrTail   string
+	LogPath      string // Archived combined output of the run
+}
+
+// RunHistoryFilter holds the query conditions for listing run history
+type RunHistoryFilter struct {
+	InstanceName string
+	TaskName     string
+	Outcome      string
+	Since        time.Time
+	Until        time.Time
+	Page         int
+	PageSize     int
+}
+
+// Create inserts a new run record marked as running
+func (h *RunHistory) Create(istName, taskName string, attempt int, command, logPath string) error {
+	h.InstanceName = istName
+	h.TaskName = taskName
+	h.Attempt = attempt
+	h.Command = command
+	h.LogPath = logPath
+	h.StartTime = time.Now()
+	h.Outcome = OutcomeRunning
+	return db.Create(h).Error
+}
+
+// Finish stores the final outcome of the run
+func (h *RunHistory) Finish(outcome string, exitCode int, stderrTail string) error {
+	h.EndTime = time.Now()
+	h.Outcome = outcome
+	h.ExitCode = exitCode
+	h.StderrTail = stderrTail
+	return db.Model(h).Updates(map[string]any{
+		"end_time":    h.EndTime,
+		"outcome":     h.Outcome,
+		"exit_code":   h.ExitCode,
+		"stderr_tail": h.StderrTail,
+	}).Error
+}
+
+// MarkInterruptedRuns marks run records that were still running when the app stopped
+func MarkInterruptedRuns() (int64, error) {
+	result := db.Model(&RunHistory{}).
+		Where("outcome = ?", OutcomeRunning).
+		Updates(map[string]any{"outcome": OutcomeInterrupted, "exit_code": -1})
+	return result.RowsAffected, result.Error
+}
+
+// ClearRunLogPath forgets the archived log of the runs that wrote to a removed log file
+func ClearRunLogPath(logPath string) error {
+	return db.Model(&RunHistory{}).Where("log_path = ?", logPath).Update("log_path", "").Error
+}
+
+// GetByID retrieves a run record by its ID
+func (h *RunHistory) GetByID(id uint) error {
+	return db.First(h, id).Error
+}
+
+// GetRunHistories retrieves run records matching the filter, newest first, and the total count
+func GetRunHistories(filter RunHistoryFilter) ([]RunHistory, int64, error) {
+	query := db.Model(&RunHistory{})
+	if filter.InstanceName != "" {
+		query = query.Where("instance_name = ?", filter.InstanceName)
+	}
+	if filter.TaskName != "" {
+		query = query.Where("task_name = ?", filter.TaskName)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("start_time >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("start_time < ?", filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var histories []RunHistory
+	err := query.Order("start_time DESC, id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&histories).Error
+	return histories, total, err
+}
+
+// ToRsp converts the run record to its API representation
+func (h *RunHistory) ToRsp() RspRunRecord {
+	return RspRunRecord{
+		ID:           h.ID,
+		InstanceName: h.InstanceName,
+		TaskName:     h.TaskName,
+		Attempt:      h.Attempt,
+		Command:      h.Command,
+		StartTime:    h.StartTime,
+		EndTime:      h.EndTime,
+		ExitCode:     h.ExitCode,
+		Outcome:      h.Outcome,
+		StderrTail:   h.StderrTail,
+		HasLog:       h.LogPath != "",
+	}
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"dacapo/backend/utils"
+	"os"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// useTestDB runs a test in an empty working directory with a fresh database and settings file
+func useTestDB(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	utils.Logger = zap.NewNop().Sugar()
+	InitDB()
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		os.Chdir(wd)
+	})
+}
+
+func TestRunHistoryLifecycle(t *testing.T) {
+	useTestDB(t)
+
+	var run RunHistory
+	if err := run.Create("game-a", "daily", 1, "py main.py daily", "logs/runs/game-a/daily.log"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Finish(OutcomeFailed, 2, "Traceback (most recent call last):"); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored RunHistory
+	if err := stored.GetByID(run.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Outcome != OutcomeFailed || stored.ExitCode != 2 || stored.StderrTail == "" || stored.EndTime.IsZero() {
+		t.Errorf("stored run = %+v, want the finished outcome", stored)
+	}
+	if rsp := stored.ToRsp(); !rsp.HasLog {
+		t.Error("ToRsp().HasLog = false for a run with an archived log")
+	}
+
+	// The log file was pruned
+	if err := ClearRunLogPath("logs/runs/game-a/daily.log"); err != nil {
+		t.Fatal(err)
+	}
+	stored.GetByID(run.ID)
+	if rsp := stored.ToRsp(); rsp.HasLog {
+		t.Error("ToRsp().HasLog = true after the log path was cleared")
+	}
+}
+
+func TestMarkInterruptedRuns(t *testing.T) {
+	useTestDB(t)
+
+	var finished, running RunHistory
+	finished.Create("game-a", "daily", 1, "daily", "")
+	finished.Finish(OutcomeSuccess, 0, "")
+	running.Create("game-a", "weekly", 1, "weekly", "")
+
+	count, err := MarkInterruptedRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("MarkInterruptedRuns() = %d, want 1", count)
+	}
+
+	running.GetByID(running.ID)
+	if running.Outcome != OutcomeInterrupted || running.ExitCode != -1 {
+		t.Errorf("running run = %s with exit code %d, want %s with -1", running.Outcome, running.ExitCode, OutcomeInterrupted)
+	}
+	finished.GetByID(finished.ID)
+	if finished.Outcome != OutcomeSuccess {
+		t.Errorf("finished run = %s, want %s", finished.Outcome, OutcomeSuccess)
+	}
+}
+
+func TestGetRunHistories(t *testing.T) {
+	useTestDB(t)
+
+	start := time.Date(2024, 6, 1, 6, 0, 0, 0, time.UTC)
+	runs := []struct {
+		instance, task, outcome string
+		hoursLater              int
+	}{
+		{"game-a", "daily", OutcomeSuccess, 0},
+		{"game-a", "weekly", OutcomeFailed, 1},
+		{"game-b", "daily", OutcomeSuccess, 2},
+		{"game-a", "daily", OutcomeTimeout, 24},
+		{"game-a", "daily", OutcomeSuccess, 48},
+	}
+	for _, r := range runs {
+		run := RunHistory{
+			InstanceName: r.instance,
+			TaskName:     r.task,
+			Outcome:      r.outcome,
+			StartTime:    start.Add(time.Duration(r.hoursLater) * time.Hour),
+		}
+		if err := db.Create(&run).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	histories, total, err := GetRunHistories(RunHistoryFilter{InstanceName: "game-a", TaskName: "daily", Page: 1, PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(histories) != 2 {
+		t.Fatalf("got %d of %d runs, want 2 of 3", len(histories), total)
+	}
+	// Newest first
+	if !histories[0].StartTime.After(histories[1].StartTime) {
+		t.Errorf("runs are not sorted newest first: %v, %v", histories[0].StartTime, histories[1].StartTime)
+	}
+
+	page2, _, _ := GetRunHistories(RunHistoryFilter{InstanceName: "game-a", TaskName: "daily", Page: 2, PageSize: 2})
+	if len(page2) != 1 || page2[0].Outcome != OutcomeSuccess || !page2[0].StartTime.Equal(start) {
+		t.Errorf("second page = %+v, want the oldest run", page2)
+	}
+
+	window, total, _ := GetRunHistories(RunHistoryFilter{
+		Since:    start.Add(time.Hour),
+		Until:    start.Add(25 * time.Hour),
+		Page:     1,
+		PageSize: 10,
+	})
+	if total != 3 || len(window) != 3 {
+		t.Errorf("runs between hour 1 and 25 = %d, want 3", total)
+	}
+
+	failed, total, _ := GetRunHistories(RunHistoryFilter{Outcome: OutcomeFailed, Page: 1, PageSize: 10})
+	if total != 1 || failed[0].TaskName != "weekly" {
+		t.Errorf("failed runs = %+v, want the weekly run", failed)
+	}
+}
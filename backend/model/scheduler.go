@@ -2,8 +2,14 @@ package model
 
 import (
 	"dacapo/backend/utils"
+	"errors"
+	"os"
 	"os/exec"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 // Task status constants
@@ -12,6 +18,7 @@ const (
 	StatusRunning  string = "running"
 	StatusUpdating string = "updating"
 	StatusFailed   string = "failed"
+	StatusPaused   string = "paused"
 )
 
 // SchedulerResult represents the result of a scheduler run
@@ -19,6 +26,7 @@ type SchedulerResult struct {
 	Success      bool
 	FailedCount  int
 	SuccessCount int
+	SkippedCount int // Instances not run because a dependency failed, included in FailedCount
 	TotalCount   int
 	FailedNames  []string
 	Results      []InstanceResult
@@ -27,16 +35,86 @@ type SchedulerResult struct {
 // InstanceResult represents the result of a single instance execution
 type InstanceResult struct {
 	Name     string
-	TaskName string // Name of the task that failed (if applicable)
+	TaskName string // Name of the first task that failed (if applicable)
 	Success  bool
+	TimedOut bool // The failing task was terminated by its timeout
+	Retries  int  // Number of retried task runs
+	Error    string
+
+	DependsOn []string // Instances of the same run that had to finish first
+	Skipped   bool     // Not run because a dependency failed
+
+	Tasks []TaskResult // Outcome of every task that was run or skipped, in execution order
+}
+
+// TaskResult represents the outcome of a single task within an instance run
+type TaskResult struct {
+	Name     string
+	Success  bool
+	Skipped  bool // Not run because a dependency failed, or nothing to do according to its exit code
+	TimedOut bool
+	Retries  int
 	Error    string
 }
 
+// SkippedTasks returns the number of tasks that were skipped in the run
+func (r *InstanceResult) SkippedTasks() int {
+	count := 0
+	for _, task := range r.Tasks {
+		if task.Skipped {
+			count++
+		}
+	}
+	return count
+}
+
+// SucceededTasks returns the number of tasks that succeeded in the run
+func (r *InstanceResult) SucceededTasks() int {
+	count := 0
+	for _, task := range r.Tasks {
+		if task.Success {
+			count++
+		}
+	}
+	return count
+}
+
+// Queue rebuild policies of scheduled runs
+const (
+	QueueRebuildAlways string = "rebuild"    // Rebuild every queue from the active tasks and their priority
+	QueueKeepEdits     string = "keep_edits" // Use a queue edited by hand since the last run as it is
+)
+
+// Shutdown policies applied when the app is closed while instances are running
+const (
+	ShutdownStop        string = "stop"         // Stop running tasks right away
+	ShutdownWaitTask    string = "wait_task"    // Let running tasks finish, but start no further task
+	ShutdownFinishQueue string = "finish_queue" // Let running instances finish their queues
+)
+
+// Task states in the last run of an instance
+const (
+	TaskSucceeded string = "succeeded"
+	TaskFailed    string = "failed"
+	TaskSkipped   string = "skipped"
+	TaskTimedOut  string = "timed_out"
+	TaskCancelled string = "cancelled"
+)
+
+// TaskState records what happened to a task in the last run of its instance
+type TaskState struct {
+	State    string `json:"state"`
+	Duration int64  `json:"duration"`  // Milliseconds, including retries
+	ExitCode int    `json:"exit_code"` // Exit code of the last attempt, -1 if it did not exit on its own
+	Reason   string `json:"reason"`    // Why the task failed or was skipped
+}
+
 // TaskQueue represents the task queue status for an instance
 type TaskQueue struct {
-	Running string   `json:"running"`
-	Waiting []string `json:"waiting"`
-	Stopped []string `json:"stopped"`
+	Running string               `json:"running"`
+	Waiting []string             `json:"waiting"`
+	Stopped []string             `json:"stopped"`
+	States  map[string]TaskState `json:"states"` // Tasks finished or skipped in the last run
 }
 
 // TaskManager handles task execution for a specific instance
@@ -44,41 +122,193 @@ type TaskManager struct {
 	InstanceName string
 	Status       string
 	Queue        TaskQueue
-	Cmd          *exec.Cmd // Current executing command
-	ManualStop   bool
-	LastError    string // Last error message
+	Cmd          atomic.Pointer[exec.Cmd] // Current executing command
+	ManualStop   atomic.Bool
+	TimedOut     atomic.Bool // Current command was terminated by the timeout watchdog
+	WindowClosed atomic.Bool // Current command was stopped because the execution window closed
+	LastError    string      // Last error message
+	LastOutcome  string      // Outcome of the last run, one of the run outcome constants
+	Paused       atomic.Bool // The running command is suspended and no further task starts
+	QueueEdited  bool        // The queue was edited by hand since the last scheduled run
+	pausedAt     time.Time
+	pausedFor    time.Duration // Total time spent paused before the current pause
+	pauseMu      sync.Mutex    // Serializes pausing and resuming with the paused time
+	statesMu     sync.Mutex    // Guards the queue while the instance runs
+}
+
+// IsBusy reports whether the instance is running or paused in the middle of a run
+func (tm *TaskManager) IsBusy() bool {
+	return tm.Status == StatusRunning || tm.Status == StatusPaused
+}
+
+// Suspend pauses the currently executing command together with its child processes
+func (tm *TaskManager) Suspend() error {
+	tm.pauseMu.Lock()
+	defer tm.pauseMu.Unlock()
+	if tm.Paused.Load() {
+		return nil
+	}
+	if cmd := tm.Cmd.Load(); isRunning(cmd) {
+		if err := utils.SuspendProcessTree(cmd.Process.Pid); err != nil {
+			return err
+		}
+	}
+	tm.Paused.Store(true)
+	tm.pausedAt = time.Now()
+	return nil
+}
+
+// Resume continues the command suspended by Suspend
+func (tm *TaskManager) Resume() error {
+	tm.pauseMu.Lock()
+	defer tm.pauseMu.Unlock()
+	if !tm.Paused.Load() {
+		return nil
+	}
+	if cmd := tm.Cmd.Load(); isRunning(cmd) {
+		if err := utils.ResumeProcessTree(cmd.Process.Pid); err != nil {
+			return err
+		}
+	}
+	tm.Paused.Store(false)
+	tm.pausedFor += time.Since(tm.pausedAt)
+	return nil
+}
+
+// PausedDuration returns the total time the instance has spent paused
+func (tm *TaskManager) PausedDuration() time.Duration {
+	tm.pauseMu.Lock()
+	defer tm.pauseMu.Unlock()
+	paused := tm.pausedFor
+	if tm.Paused.Load() {
+		paused += time.Since(tm.pausedAt)
+	}
+	return paused
+}
+
+// SetTaskState records the state of a task in the current run
+func (tm *TaskManager) SetTaskState(name string, state TaskState) {
+	tm.statesMu.Lock()
+	defer tm.statesMu.Unlock()
+	if tm.Queue.States == nil {
+		tm.Queue.States = make(map[string]TaskState)
+	}
+	tm.Queue.States[name] = state
+}
+
+// ResetTaskStates forgets the task states of the previous run
+func (tm *TaskManager) ResetTaskStates() {
+	tm.statesMu.Lock()
+	defer tm.statesMu.Unlock()
+	tm.Queue.States = make(map[string]TaskState)
 }
 
-// SwitchRun switches to the next task in the queue and returns its name
-func (tm *TaskManager) SwitchRun() string {
-	tm.RemoveRun()
+// QueueSnapshot returns a copy of the task queue that is safe to use while the instance runs
+func (tm *TaskManager) QueueSnapshot() TaskQueue {
+	tm.statesMu.Lock()
+	defer tm.statesMu.Unlock()
 
-	if len(tm.Queue.Waiting) == 0 {
-		return ""
+	states := make(map[string]TaskState, len(tm.Queue.States))
+	for name, state := range tm.Queue.States {
+		states[name] = state
 	}
-	nextTask := tm.Queue.Waiting[0]
-	tm.Queue.Waiting = tm.Queue.Waiting[1:]
-	tm.Queue.Running = nextTask
+	return TaskQueue{
+		Running: tm.Queue.Running,
+		Waiting: append([]string{}, tm.Queue.Waiting...),
+		Stopped: append([]string{}, tm.Queue.Stopped...),
+		States:  states,
+	}
+}
 
-	return nextTask
+// StartTask moves a waiting task to running, the previously running task is moved to the stopped list
+func (tm *TaskManager) StartTask(name string) {
+	tm.statesMu.Lock()
+	defer tm.statesMu.Unlock()
+	tm.removeRun()
+	tm.Queue.Waiting = slices.DeleteFunc(tm.Queue.Waiting, func(n string) bool { return n == name })
+	tm.Queue.Running = name
+}
+
+// SkipTask moves a waiting task to the stopped list without running it
+func (tm *TaskManager) SkipTask(name string) {
+	tm.statesMu.Lock()
+	defer tm.statesMu.Unlock()
+	tm.Queue.Waiting = slices.DeleteFunc(tm.Queue.Waiting, func(n string) bool { return n == name })
+	tm.Queue.Stopped = append(tm.Queue.Stopped, name)
+}
+
+// SetRunning shows a task as running without taking it from the waiting list, an empty name clears it
+func (tm *TaskManager) SetRunning(name string) {
+	tm.statesMu.Lock()
+	defer tm.statesMu.Unlock()
+	tm.Queue.Running = name
 }
 
 // RemoveRun moves the currently running task to the stopped list
 func (tm *TaskManager) RemoveRun() {
+	tm.statesMu.Lock()
+	defer tm.statesMu.Unlock()
+	tm.removeRun()
+}
+
+// removeRun is RemoveRun for callers that already hold the queue lock
+func (tm *TaskManager) removeRun() {
 	if tm.Queue.Running != "" {
 		tm.Queue.Stopped = append(tm.Queue.Stopped, tm.Queue.Running)
 		tm.Queue.Running = ""
 	}
 }
 
-// Cancel terminates the current task execution
-func (tm *TaskManager) Cancel() {
-	if tm.Cmd != nil && tm.Cmd.Process != nil {
-		if err := tm.Cmd.Process.Kill(); err != nil {
+// RequeueRun moves the currently running task back to the front of the waiting list
+func (tm *TaskManager) RequeueRun() {
+	tm.statesMu.Lock()
+	defer tm.statesMu.Unlock()
+	if tm.Queue.Running != "" {
+		tm.Queue.Waiting = append([]string{tm.Queue.Running}, tm.Queue.Waiting...)
+		tm.Queue.Running = ""
+	}
+}
+
+// Kill terminates the currently executing command together with its child processes
+// It blocks until the processes exit or the configured grace period has passed
+func (tm *TaskManager) Kill() {
+	cmd := tm.Cmd.Load()
+	if !isRunning(cmd) {
+		return
+	}
+
+	// A suspended command could not react to the termination request
+	if err := tm.Resume(); err != nil {
+		utils.Logger.Warnf("[%s]: Failed to resume process before terminating: %v", tm.InstanceName, err)
+	}
+
+	// LoadSettings falls back to defaults on error
+	settings, _ := LoadSettings()
+	grace := time.Duration(settings.StopGracePeriod) * time.Second
+	if err := utils.TerminateProcessTree(cmd.Process.Pid, grace); err != nil {
+		utils.Logger.Errorf("[%s]: Failed to terminate process tree: %v", tm.InstanceName, err)
+		// Fall back to killing the direct child
+		if err := cmd.Process.Kill(); err != nil {
 			utils.Logger.Errorf("[%s]: Failed to kill process: %v", tm.InstanceName, err)
 		}
 	}
-	tm.ManualStop = true
+}
+
+// isRunning reports whether cmd has started and not been waited for
+// An exited command has been reaped and its PID may already belong to another process
+func isRunning(cmd *exec.Cmd) bool {
+	if cmd == nil || cmd.Process == nil {
+		return false
+	}
+	// Signal knows whether the process is done without racing with Wait
+	return !errors.Is(cmd.Process.Signal(syscall.Signal(0)), os.ErrProcessDone)
+}
+
+// Cancel terminates the current task execution
+func (tm *TaskManager) Cancel() {
+	// Set before killing so that the exit is not reported as a failure
+	tm.ManualStop.Store(true)
+	tm.Kill()
 }
 
 // Scheduler manages task execution across multiple instances
@@ -152,7 +382,7 @@ func (s *Scheduler) SyncWithDatabase() {
 			}
 
 			waitingQueue, stoppedQueue := istInfo.GetTaskQueue()
-			s.TaskManagers[istName] = &TaskManager{
+			tm := &TaskManager{
 				InstanceName: istName,
 				Status:       StatusPending,
 				Queue: TaskQueue{
@@ -161,6 +391,17 @@ func (s *Scheduler) SyncWithDatabase() {
 					Stopped: stoppedQueue,
 				},
 			}
+
+			// Continue from the state saved before the last shutdown
+			if state, ok, err := GetInstanceState(istName); err != nil {
+				utils.Logger.Warnf("[%s]: Failed to load saved state: %v", istName, err)
+			} else if ok {
+				tm.restoreState(state, &istInfo)
+				if tm.LastOutcome == OutcomeInterrupted {
+					utils.Logger.Warnf("[%s]: %s", istName, tm.LastError)
+				}
+			}
+			s.TaskManagers[istName] = tm
 		}
 	}
 
@@ -187,11 +428,7 @@ func (s *Scheduler) GetTaskQueues() map[string]TaskQueue {
 
 	result := make(map[string]TaskQueue, len(s.TaskManagers))
 	for name, tm := range s.TaskManagers {
-		result[name] = TaskQueue{
-			Running: tm.Queue.Running,
-			Waiting: append([]string{}, tm.Queue.Waiting...),
-			Stopped: append([]string{}, tm.Queue.Stopped...),
-		}
+		result[name] = tm.QueueSnapshot()
 	}
 	return result
 }
@@ -204,15 +441,53 @@ func (s *Scheduler) UpdateQueue(queues map[string]TaskQueue) {
 	// utils.Logger.Debugf("Updating queues: %+v", queues)
 	for istName, queue := range queues {
 		if tm, ok := s.TaskManagers[istName]; ok {
+			// Task states are owned by the scheduler and kept as they are
+			tm.statesMu.Lock()
 			tm.Queue = TaskQueue{
 				Running: queue.Running,
 				Waiting: append([]string{}, queue.Waiting...),
 				Stopped: append([]string{}, queue.Stopped...),
+				States:  tm.Queue.States,
 			}
+			tm.statesMu.Unlock()
+			tm.QueueEdited = true
 		}
 	}
 }
 
+// RebuildQueue fills the queue of an idle instance from its active tasks sorted by priority
+// With the keep edits policy a queue edited by hand since the last scheduled run is left as it is
+func (s *Scheduler) RebuildQueue(istName, policy string) error {
+	var istInfo InstanceInfo
+	if err := istInfo.GetByName(istName); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tm, ok := s.TaskManagers[istName]
+	if !ok || tm.IsBusy() || tm.Status == StatusUpdating {
+		return nil
+	}
+	if policy == QueueKeepEdits && tm.QueueEdited {
+		tm.QueueEdited = false
+		return nil
+	}
+
+	waiting, stopped := istInfo.GetTaskQueue()
+	tm.statesMu.Lock()
+	tm.Queue = TaskQueue{
+		Running: "",
+		Waiting: waiting,
+		Stopped: stopped,
+		States:  tm.Queue.States,
+	}
+	tm.statesMu.Unlock()
+	tm.QueueEdited = false
+	return nil
+}
+
 // UpdateTaskManagerStatus updates the status of a task manager
 func (s *Scheduler) UpdateTaskManagerStatus(istName, status string) {
 	s.mu.Lock()
@@ -224,12 +499,33 @@ func (s *Scheduler) UpdateTaskManagerStatus(istName, status string) {
 	}
 }
 
+// ClaimIdle sets an idle instance to running in one step, so that concurrent requests cannot both start it
+// It returns the status the instance had before, or false with the current status if it is busy or updating
+func (s *Scheduler) ClaimIdle(istName string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tm, ok := s.TaskManagers[istName]
+	if !ok {
+		return "", false
+	}
+	if tm.IsBusy() || tm.Status == StatusUpdating {
+		return tm.Status, false
+	}
+	previous := tm.Status
+	tm.Status = StatusRunning
+	utils.Logger.Infof("[%s]: status: %s", istName, StatusRunning)
+	return previous, true
+}
+
 // CancelTask cancels task execution for an instance
 func (s *Scheduler) CancelTask(istName string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	tm, ok := s.TaskManagers[istName]
+	s.mu.Unlock()
 
-	if tm, ok := s.TaskManagers[istName]; ok {
+	// Terminating may wait for the grace period, so it must not hold the lock
+	if ok {
 		tm.Cancel()
 	}
 }
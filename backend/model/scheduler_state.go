@@ -0,0 +1,115 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InstanceState stores the scheduler state of an instance so that it survives a restart
+type InstanceState struct {
+	gorm.Model
+
+	InstanceName string    `gorm:"uniqueIndex;not null"`
+	Status       string    // Instance status when it was saved, running or paused if the run was interrupted
+	Queue        TaskQueue `gorm:"serializer:json"`
+	QueueEdited  bool
+	LastError    string
+	LastOutcome  string // Outcome of the last run, one of the run outcome constants
+}
+
+// GetInstanceState returns the saved state of an instance, false if it has never been saved
+func GetInstanceState(istName string) (*InstanceState, bool, error) {
+	var state InstanceState
+	result := db.Where("instance_name = ?", istName).Limit(1).Find(&state)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	return &state, result.RowsAffected > 0, nil
+}
+
+// DeleteInstanceState permanently removes the saved state of an instance
+func DeleteInstanceState(istName string) error {
+	return db.Unscoped().Where("instance_name = ?", istName).Delete(&InstanceState{}).Error
+}
+
+// SaveState creates or updates the saved state of the instance
+func (tm *TaskManager) SaveState() error {
+	state := InstanceState{
+		InstanceName: tm.InstanceName,
+		Status:       tm.Status,
+		Queue:        tm.QueueSnapshot(),
+		QueueEdited:  tm.QueueEdited,
+		LastError:    tm.LastError,
+		LastOutcome:  tm.LastOutcome,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "instance_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"status", "queue", "queue_edited", "last_error", "last_outcome", "updated_at",
+		}),
+	}).Create(&state).Error
+}
+
+// restoreState applies a saved state to a new task manager
+// Tasks removed from the instance are dropped and new tasks keep their place from the task list.
+// A run that was still going on when the state was saved is marked as interrupted
+func (tm *TaskManager) restoreState(state *InstanceState, istInfo *InstanceInfo) {
+	known := func(name string) bool { return istInfo.GetTaskByName(name) != nil }
+	saved := state.Queue
+
+	queue := TaskQueue{
+		Waiting: slices.DeleteFunc(slices.Clone(saved.Waiting), func(name string) bool { return !known(name) }),
+		Stopped: slices.DeleteFunc(slices.Clone(saved.Stopped), func(name string) bool { return !known(name) }),
+		States:  make(map[string]TaskState, len(saved.States)),
+	}
+	for name, taskState := range saved.States {
+		if known(name) {
+			queue.States[name] = taskState
+		}
+	}
+
+	interrupted := state.Status == StatusRunning || state.Status == StatusPaused
+	if saved.Running != "" && known(saved.Running) {
+		if interrupted {
+			// Run the interrupted task first next time
+			queue.Waiting = append([]string{saved.Running}, queue.Waiting...)
+			queue.States[saved.Running] = TaskState{
+				State:    TaskCancelled,
+				ExitCode: -1,
+				Reason:   "Interrupted by a restart",
+			}
+		} else {
+			queue.Stopped = append(queue.Stopped, saved.Running)
+		}
+	}
+
+	waiting, stopped := istInfo.GetTaskQueue()
+	for _, name := range waiting {
+		if !slices.Contains(queue.Waiting, name) && !slices.Contains(queue.Stopped, name) {
+			queue.Waiting = append(queue.Waiting, name)
+		}
+	}
+	for _, name := range stopped {
+		if !slices.Contains(queue.Waiting, name) && !slices.Contains(queue.Stopped, name) {
+			queue.Stopped = append(queue.Stopped, name)
+		}
+	}
+
+	tm.Queue = queue
+	tm.QueueEdited = state.QueueEdited
+	tm.LastError = state.LastError
+	tm.LastOutcome = state.LastOutcome
+	if state.Status == StatusFailed {
+		tm.Status = StatusFailed
+	}
+	if interrupted {
+		tm.LastOutcome = OutcomeInterrupted
+		tm.LastError = "Run interrupted by a restart"
+		if saved.Running != "" {
+			tm.LastError = fmt.Sprintf("Run interrupted by a restart while task %s was running", saved.Running)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSaveState(t *testing.T) {
+	useTestDB(t)
+
+	tm := &TaskManager{
+		InstanceName: "game-a",
+		Status:       StatusRunning,
+		Queue:        TaskQueue{Running: "daily", Waiting: []string{"weekly"}, Stopped: []string{"login"}},
+	}
+	tm.SetTaskState("login", TaskState{State: TaskSucceeded, Duration: 800})
+	if err := tm.SaveState(); err != nil {
+		t.Fatal(err)
+	}
+	// Saved again on every change of the instance
+	tm.Status = StatusFailed
+	tm.LastError = "task daily failed"
+	tm.LastOutcome = OutcomeFailed
+	if err := tm.SaveState(); err != nil {
+		t.Fatalf("SaveState() of a saved instance error = %v", err)
+	}
+
+	state, ok, err := GetInstanceState("game-a")
+	if err != nil || !ok {
+		t.Fatalf("GetInstanceState() = %v, %v", ok, err)
+	}
+	if state.Status != StatusFailed || state.LastError != "task daily failed" || state.LastOutcome != OutcomeFailed {
+		t.Errorf("saved state = %+v, want the failed status of the second save", state)
+	}
+	if state.Queue.Running != "daily" || !slices.Equal(state.Queue.Waiting, []string{"weekly"}) || state.Queue.States["login"].Duration != 800 {
+		t.Errorf("saved queue = %+v", state.Queue)
+	}
+
+	if err := DeleteInstanceState("game-a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := GetInstanceState("game-a"); ok {
+		t.Error("state still saved after DeleteInstanceState()")
+	}
+}
+
+func TestRestoreState(t *testing.T) {
+	active, inactive := true, false
+	// "event" was removed from the instance and "raid" added since the state was saved
+	istInfo := &InstanceInfo{Name: "game-a", Tasks: []TaskInfo{
+		{Name: "login", Active: &active, Priority: 9},
+		{Name: "daily", Active: &active, Priority: 5},
+		{Name: "weekly", Active: &active, Priority: 3},
+		{Name: "raid", Active: &active, Priority: 1},
+		{Name: "cleanup", Active: &inactive},
+	}}
+	saved := TaskQueue{
+		Running: "daily",
+		Waiting: []string{"event", "weekly"},
+		Stopped: []string{"login"},
+		States:  map[string]TaskState{"login": {State: TaskSucceeded}, "event": {State: TaskFailed}},
+	}
+
+	t.Run("interrupted run", func(t *testing.T) {
+		tm := &TaskManager{InstanceName: "game-a", Status: StatusPending}
+		tm.restoreState(&InstanceState{Status: StatusPaused, Queue: saved}, istInfo)
+
+		if want := []string{"daily", "weekly", "raid"}; !slices.Equal(tm.Queue.Waiting, want) {
+			t.Errorf("waiting = %v, want %v", tm.Queue.Waiting, want)
+		}
+		if want := []string{"login", "cleanup"}; !slices.Equal(tm.Queue.Stopped, want) {
+			t.Errorf("stopped = %v, want %v", tm.Queue.Stopped, want)
+		}
+		if state := tm.Queue.States["daily"]; state.State != TaskCancelled {
+			t.Errorf("state of the interrupted task = %+v, want cancelled", state)
+		}
+		if _, ok := tm.Queue.States["event"]; ok {
+			t.Error("state of a removed task was restored")
+		}
+		if tm.LastOutcome != OutcomeInterrupted || tm.LastError == "" || tm.Status != StatusPending {
+			t.Errorf("restored %s with outcome %s, error %q, want a pending instance with an interrupted run", tm.Status, tm.LastOutcome, tm.LastError)
+		}
+	})
+
+	t.Run("failed instance", func(t *testing.T) {
+		tm := &TaskManager{InstanceName: "game-a", Status: StatusPending}
+		tm.restoreState(&InstanceState{
+			Status:      StatusFailed,
+			Queue:       saved,
+			LastError:   "task daily failed",
+			LastOutcome: OutcomeFailed,
+			QueueEdited: true,
+		}, istInfo)
+
+		if tm.Status != StatusFailed || tm.LastError != "task daily failed" || tm.LastOutcome != OutcomeFailed || !tm.QueueEdited {
+			t.Errorf("restored %+v, want the saved failure", tm)
+		}
+		// Only a run in progress puts its task back in front
+		if tm.Queue.Running != "" || !slices.Contains(tm.Queue.Stopped, "daily") || slices.Contains(tm.Queue.Waiting, "daily") {
+			t.Errorf("queue = %+v, want daily stopped", tm.Queue)
+		}
+	})
+}
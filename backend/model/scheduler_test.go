@@ -0,0 +1,244 @@
+package model
+
+import (
+	"dacapo/backend/utils"
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lookPath skips tests that need a command not available on this system
+func lookPath(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s is not available: %v", name, err)
+	}
+}
+
+// startSleep runs a command that keeps going until it is stopped, the way RunCommand records it
+func startSleep(t *testing.T, tm *TaskManager) chan struct{} {
+	t.Helper()
+	lookPath(t, "sleep")
+	cmd := exec.Command("sleep", "30")
+	utils.SetProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	tm.Cmd.Store(cmd)
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		tm.Cmd.Store(nil)
+		close(exited)
+	}()
+	return exited
+}
+
+func TestTaskManagerCancelWhileRunning(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kill reads the grace period from the settings file
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tm := &TaskManager{InstanceName: "game-a"}
+	exited := startSleep(t, tm)
+
+	if err := tm.Suspend(); err != nil {
+		t.Fatalf("Suspend() error = %v", err)
+	}
+	if err := tm.Resume(); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	// Stop requests come from other goroutines than the one waiting for the command
+	done := make(chan struct{})
+	go func() {
+		tm.Cancel()
+		close(done)
+	}()
+	tm.Cancel()
+	<-done
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command still running after Cancel()")
+	}
+	if !tm.ManualStop.Load() {
+		t.Error("ManualStop = false after Cancel()")
+	}
+}
+
+func TestIsRunning(t *testing.T) {
+	if isRunning(nil) {
+		t.Error("isRunning(nil) = true")
+	}
+
+	lookPath(t, "true")
+	cmd := exec.Command("true")
+	if isRunning(cmd) {
+		t.Error("isRunning() = true before the command started")
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	// The PID of a reaped command must not be signalled anymore
+	if isRunning(cmd) {
+		t.Error("isRunning() = true after the command was waited for")
+	}
+}
+
+func TestClaimIdle(t *testing.T) {
+	utils.Logger = zap.NewNop().Sugar()
+	s := &Scheduler{TaskManagers: map[string]*TaskManager{
+		"idle":     {InstanceName: "idle", Status: StatusPending},
+		"failed":   {InstanceName: "failed", Status: StatusFailed, LastError: "task daily failed"},
+		"updating": {InstanceName: "updating", Status: StatusUpdating},
+	}}
+
+	if previous, ok := s.ClaimIdle("idle"); !ok || previous != StatusPending {
+		t.Errorf("ClaimIdle(idle) = %s, %v, want %s, true", previous, ok, StatusPending)
+	}
+	// The second request finds the instance running
+	if status, ok := s.ClaimIdle("idle"); ok || status != StatusRunning {
+		t.Errorf("ClaimIdle(idle) again = %s, %v, want %s, false", status, ok, StatusRunning)
+	}
+
+	// A failed instance can run a single task and gets its status back afterwards
+	if previous, ok := s.ClaimIdle("failed"); !ok || previous != StatusFailed {
+		t.Errorf("ClaimIdle(failed) = %s, %v, want %s, true", previous, ok, StatusFailed)
+	}
+	if tm := s.TaskManagers["failed"]; tm.LastError == "" {
+		t.Error("ClaimIdle(failed) cleared the last error")
+	}
+
+	if _, ok := s.ClaimIdle("updating"); ok {
+		t.Error("ClaimIdle(updating) = true, want false")
+	}
+	if _, ok := s.ClaimIdle("missing"); ok {
+		t.Error("ClaimIdle(missing) = true, want false")
+	}
+}
+
+func TestQueueSnapshot(t *testing.T) {
+	tm := &TaskManager{Queue: TaskQueue{Waiting: []string{"login", "daily", "weekly"}}}
+	tm.StartTask("login")
+	tm.SetTaskState("login", TaskState{State: TaskSucceeded, Duration: 1200})
+	tm.StartTask("daily")
+
+	snapshot := tm.QueueSnapshot()
+	if snapshot.Running != "daily" || len(snapshot.Waiting) != 1 || len(snapshot.Stopped) != 1 {
+		t.Fatalf("QueueSnapshot() = %+v, want daily running after login", snapshot)
+	}
+	if state := snapshot.States["login"]; state.State != TaskSucceeded || state.Duration != 1200 {
+		t.Errorf("state of login = %+v, want succeeded in 1200ms", state)
+	}
+
+	snapshot.Waiting[0] = "edited"
+	if tm.Queue.Waiting[0] != "weekly" {
+		t.Errorf("editing the snapshot changed the queue to %v", tm.Queue.Waiting)
+	}
+
+	// The run goes on while the snapshot is being sent
+	tm.SetTaskState("daily", TaskState{State: TaskFailed, ExitCode: 1, Reason: "exit status 1"})
+	tm.StartTask("weekly")
+	if _, ok := snapshot.States["daily"]; ok || snapshot.Running != "daily" {
+		t.Errorf("snapshot changed with the queue: %+v", snapshot)
+	}
+
+	tm.ResetTaskStates()
+	if states := tm.QueueSnapshot().States; len(states) != 0 {
+		t.Errorf("states after ResetTaskStates() = %v, want none", states)
+	}
+}
+
+func TestPausedDuration(t *testing.T) {
+	// Pausing between tasks has no command to suspend
+	tm := &TaskManager{InstanceName: "game-a"}
+	if tm.PausedDuration() != 0 {
+		t.Fatalf("PausedDuration() = %v before pausing", tm.PausedDuration())
+	}
+
+	tm.Suspend()
+	time.Sleep(20 * time.Millisecond)
+	// A second pause request keeps the first pause time
+	tm.Suspend()
+	time.Sleep(20 * time.Millisecond)
+	if paused := tm.PausedDuration(); paused < 40*time.Millisecond {
+		t.Errorf("PausedDuration() = %v while paused, want at least 40ms", paused)
+	}
+
+	tm.Resume()
+	tm.Resume()
+	first := tm.PausedDuration()
+	time.Sleep(20 * time.Millisecond)
+	if tm.Paused.Load() || tm.PausedDuration() != first {
+		t.Errorf("PausedDuration() went from %v to %v after Resume()", first, tm.PausedDuration())
+	}
+
+	// Further pauses add up
+	tm.Suspend()
+	time.Sleep(20 * time.Millisecond)
+	tm.Resume()
+	if paused := tm.PausedDuration(); paused < first+20*time.Millisecond {
+		t.Errorf("PausedDuration() = %v after a second pause, want at least %v", paused, first+20*time.Millisecond)
+	}
+}
+
+func TestRebuildQueue(t *testing.T) {
+	useTestDB(t)
+	inactive := false
+	ist := InstanceInfo{Name: "game-a", TemplateName: "game", Tasks: []TaskInfo{
+		{Name: "login", Priority: 9},
+		{Name: "daily", Priority: 5},
+		{Name: "event", Active: &inactive},
+		{Name: "weekly", Priority: 7},
+	}}
+	if err := db.Create(&ist).Error; err != nil {
+		t.Fatal(err)
+	}
+	tm := &TaskManager{InstanceName: "game-a", Status: StatusPending}
+	s := &Scheduler{TaskManagers: map[string]*TaskManager{"game-a": tm}}
+	rebuilt := []string{"login", "weekly", "daily"}
+
+	// A queue edited by hand is used once by the next scheduled run
+	s.UpdateQueue(map[string]TaskQueue{"game-a": {Waiting: []string{"event"}}})
+	tm.SetTaskState("login", TaskState{State: TaskSucceeded})
+	if err := s.RebuildQueue("game-a", QueueKeepEdits); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tm.Queue.Waiting, []string{"event"}) || tm.QueueEdited {
+		t.Errorf("queue after keeping edits = %v (edited %v), want [event] and the edit used up", tm.Queue.Waiting, tm.QueueEdited)
+	}
+	s.RebuildQueue("game-a", QueueKeepEdits)
+	if !slices.Equal(tm.Queue.Waiting, rebuilt) || !slices.Equal(tm.Queue.Stopped, []string{"event"}) {
+		t.Errorf("queue of the following run = %+v, want %v waiting and event stopped", tm.Queue, rebuilt)
+	}
+	if _, ok := tm.Queue.States["login"]; !ok {
+		t.Error("RebuildQueue() dropped the task states")
+	}
+
+	s.UpdateQueue(map[string]TaskQueue{"game-a": {Waiting: []string{"event"}}})
+	s.RebuildQueue("game-a", QueueRebuildAlways)
+	if !slices.Equal(tm.Queue.Waiting, rebuilt) {
+		t.Errorf("queue rebuilt despite edits = %v, want %v", tm.Queue.Waiting, rebuilt)
+	}
+
+	// The queue of a running instance belongs to the run
+	s.UpdateQueue(map[string]TaskQueue{"game-a": {Running: "daily", Waiting: []string{"weekly"}}})
+	tm.Status = StatusRunning
+	s.RebuildQueue("game-a", QueueRebuildAlways)
+	if tm.Queue.Running != "daily" || !slices.Equal(tm.Queue.Waiting, []string{"weekly"}) {
+		t.Errorf("queue of a running instance changed to %+v", tm.Queue)
+	}
+}
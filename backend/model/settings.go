@@ -9,14 +9,25 @@ import (
 )
 
 type AppSettings struct {
-	Language          string `yaml:"language"`
-	RunOnStartup      bool   `yaml:"run_on_startup"`
-	SchedulerCron     string `yaml:"scheduler_cron"`
-	AutoActionTrigger string `yaml:"auto_action_trigger"`
-	AutoActionCron    string `yaml:"auto_action_cron"`
-	AutoActionType    string `yaml:"auto_action_type"`
-	MaxBgConcurrent   int    `yaml:"max_bg_concurrent"`
-	ServerChanSendKey string `yaml:"serverchan_sendkey"`
+	Language            string `yaml:"language"`
+	RunOnStartup        bool   `yaml:"run_on_startup"`
+	SchedulerCron       string `yaml:"scheduler_cron"`
+	AutoActionTrigger   string `yaml:"auto_action_trigger"`
+	AutoActionCron      string `yaml:"auto_action_cron"`
+	AutoActionType      string `yaml:"auto_action_type"`
+	MaxBgConcurrent     int    `yaml:"max_bg_concurrent"`
+	ServerChanSendKey   string `yaml:"serverchan_sendkey"`
+	RunLogRetentionDays int    `yaml:"run_log_retention_days"`
+	RunLogMaxFiles      int    `yaml:"run_log_max_files"`
+	StopGracePeriod     int    `yaml:"stop_grace_period"`
+	CatchUpWindow       int    `yaml:"catch_up_window"`
+	SchedulerCatchUp    string `yaml:"scheduler_catch_up"`
+	BlackoutPeriods     string `yaml:"blackout_periods"`
+	QueueRebuild        string `yaml:"queue_rebuild"`
+	OrphanPolicy        string `yaml:"orphan_policy"`
+	ShutdownPolicy      string `yaml:"shutdown_policy"`
+	ShutdownTimeout     int    `yaml:"shutdown_timeout"`
+	ResourceCapacity    string `yaml:"resource_capacity"`
 }
 
 const settingsPath = "settings.yml"
@@ -25,14 +36,25 @@ const settingsPath = "settings.yml"
 func LoadSettings() (*AppSettings, error) {
 	settings := &AppSettings{
 		// Set default values
-		Language:          "", // Empty initially for auto-detection
-		RunOnStartup:      false,
-		SchedulerCron:     "",
-		AutoActionTrigger: "scheduler_end",
-		AutoActionCron:    "",
-		AutoActionType:    "none",
-		MaxBgConcurrent:   0,  // Default to 0 (no limit)
-		ServerChanSendKey: "", // Default to empty (disabled)
+		Language:            "", // Empty initially for auto-detection
+		RunOnStartup:        false,
+		SchedulerCron:       "",
+		AutoActionTrigger:   "scheduler_end",
+		AutoActionCron:      "",
+		AutoActionType:      "none",
+		MaxBgConcurrent:     0,                  // Default to 0 (no limit)
+		ServerChanSendKey:   "",                 // Default to empty (disabled)
+		RunLogRetentionDays: 30,                 // Keep per-run logs for 30 days, 0 keeps forever
+		RunLogMaxFiles:      0,                  // Default to 0 (no limit per instance)
+		StopGracePeriod:     10,                 // Seconds to wait after SIGTERM before killing a stopped task
+		CatchUpWindow:       12,                 // Hours to look back for missed scheduled runs on startup, 0 disables
+		SchedulerCatchUp:    "once",             // Catch-up policy of the scheduler cron
+		BlackoutPeriods:     "",                 // Time windows in which no task is started, e.g. "wed 04:00-06:00"
+		QueueRebuild:        QueueRebuildAlways, // Queue rebuild policy of scheduled runs
+		OrphanPolicy:        OrphanAdopt,        // What to do with task processes left running by a crash
+		ShutdownPolicy:      ShutdownStop,       // What to do with running instances when the app is closed
+		ShutdownTimeout:     300,                // Seconds to wait for running instances on close before stopping them, 0 waits without limit
+		ResourceCapacity:    "",                 // How many holders each named resource allows, e.g. "gpu:2", unlisted resources allow one
 	} // Create settings directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
 		return settings, err
@@ -114,6 +136,61 @@ func UpdateSettings(updates *ReqUpdateSettings) error {
 	if updates.ServerChanSendKey != nil {
 		settings.ServerChanSendKey = *updates.ServerChanSendKey
 	}
+	if updates.RunLogRetentionDays != nil {
+		if *updates.RunLogRetentionDays >= 0 {
+			settings.RunLogRetentionDays = *updates.RunLogRetentionDays
+		}
+	}
+	if updates.RunLogMaxFiles != nil {
+		if *updates.RunLogMaxFiles >= 0 {
+			settings.RunLogMaxFiles = *updates.RunLogMaxFiles
+		}
+	}
+	if updates.StopGracePeriod != nil {
+		if *updates.StopGracePeriod >= 0 {
+			settings.StopGracePeriod = *updates.StopGracePeriod
+		}
+	}
+	if updates.CatchUpWindow != nil {
+		if *updates.CatchUpWindow >= 0 {
+			settings.CatchUpWindow = *updates.CatchUpWindow
+		}
+	}
+	if updates.SchedulerCatchUp != nil {
+		if *updates.SchedulerCatchUp == CatchUpOnce || *updates.SchedulerCatchUp == CatchUpAll || *updates.SchedulerCatchUp == CatchUpSkip {
+			settings.SchedulerCatchUp = *updates.SchedulerCatchUp
+		}
+	}
+	if updates.BlackoutPeriods != nil {
+		if IsValidTimeWindows(*updates.BlackoutPeriods) {
+			settings.BlackoutPeriods = *updates.BlackoutPeriods
+		}
+	}
+	if updates.QueueRebuild != nil {
+		if *updates.QueueRebuild == QueueRebuildAlways || *updates.QueueRebuild == QueueKeepEdits {
+			settings.QueueRebuild = *updates.QueueRebuild
+		}
+	}
+	if updates.OrphanPolicy != nil {
+		if *updates.OrphanPolicy == OrphanAdopt || *updates.OrphanPolicy == OrphanKill || *updates.OrphanPolicy == OrphanReport {
+			settings.OrphanPolicy = *updates.OrphanPolicy
+		}
+	}
+	if updates.ShutdownPolicy != nil {
+		if *updates.ShutdownPolicy == ShutdownStop || *updates.ShutdownPolicy == ShutdownWaitTask || *updates.ShutdownPolicy == ShutdownFinishQueue {
+			settings.ShutdownPolicy = *updates.ShutdownPolicy
+		}
+	}
+	if updates.ShutdownTimeout != nil {
+		if *updates.ShutdownTimeout >= 0 {
+			settings.ShutdownTimeout = *updates.ShutdownTimeout
+		}
+	}
+	if updates.ResourceCapacity != nil {
+		if IsValidResources(*updates.ResourceCapacity) {
+			settings.ResourceCapacity = *updates.ResourceCapacity
+		}
+	}
 
 	return SaveSettings(settings)
 }
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Policies for a task still running when its execution window closes
+const (
+	WindowPolicyFinish string = "finish" // Let the running task finish, start no further tasks
+	WindowPolicyStop   string = "stop"   // Stop the running task gracefully
+)
+
+// weekdayNames maps abbreviations accepted in time windows to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// TimeWindow is a daily time range such as "04:00-23:30", optionally limited to a weekday such as "wed 04:00-06:00"
+type TimeWindow struct {
+	AnyDay  bool
+	Weekday time.Weekday
+	Start   int // Minutes since midnight
+	End     int // Minutes since midnight, a range with End <= Start crosses midnight
+}
+
+// ParseTimeWindows parses a comma separated list of time windows, an empty string gives no windows
+func ParseTimeWindows(expr string) ([]TimeWindow, error) {
+	windows := make([]TimeWindow, 0)
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		window := TimeWindow{AnyDay: true}
+		fields := strings.Fields(part)
+		if len(fields) == 2 {
+			weekday, ok := weekdayNames[strings.ToLower(fields[0])]
+			if !ok {
+				return nil, fmt.Errorf("invalid weekday in time window: %s", part)
+			}
+			window.AnyDay = false
+			window.Weekday = weekday
+			fields = fields[1:]
+		}
+		if len(fields) != 1 {
+			return nil, fmt.Errorf("invalid time window: %s", part)
+		}
+
+		startStr, endStr, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid time window: %s", part)
+		}
+		var err error
+		if window.Start, err = parseClock(startStr); err != nil {
+			return nil, fmt.Errorf("invalid time window %s: %w", part, err)
+		}
+		if window.End, err = parseClock(endStr); err != nil {
+			return nil, fmt.Errorf("invalid time window %s: %w", part, err)
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight, "24:00" is allowed as the end of a day
+func parseClock(value string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hour, &minute); err != nil {
+		return 0, fmt.Errorf("invalid time: %s", value)
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("invalid time: %s", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// Contains reports whether t falls inside the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End && w.matchDay(t.Weekday())
+	}
+
+	// The part after midnight belongs to the previous day
+	if minute >= w.Start {
+		return w.matchDay(t.Weekday())
+	}
+	return minute < w.End && w.matchDay((t.Weekday()+6)%7)
+}
+
+// matchDay reports whether the window applies to a weekday
+func (w TimeWindow) matchDay(weekday time.Weekday) bool {
+	return w.AnyDay || w.Weekday == weekday
+}
+
+// InTimeWindows reports whether t falls inside any of the windows
+func InTimeWindows(windows []TimeWindow, t time.Time) bool {
+	for _, window := range windows {
+		if window.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidTimeWindows reports whether a time window list can be parsed
+func IsValidTimeWindows(expr string) bool {
+	_, err := ParseTimeWindows(expr)
+	return err == nil
+}
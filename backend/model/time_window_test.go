@@ -0,0 +1,92 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTimeWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    []TimeWindow
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			expr: "",
+			want: []TimeWindow{},
+		},
+		{
+			name: "single window",
+			expr: "04:00-23:30",
+			want: []TimeWindow{{AnyDay: true, Start: 4 * 60, End: 23*60 + 30}},
+		},
+		{
+			name: "weekday and several windows",
+			expr: " Sat 08:00-12:00 , 22:00-02:00,",
+			want: []TimeWindow{
+				{Weekday: time.Saturday, Start: 8 * 60, End: 12 * 60},
+				{AnyDay: true, Start: 22 * 60, End: 2 * 60},
+			},
+		},
+		{
+			name: "end of day",
+			expr: "00:00-24:00",
+			want: []TimeWindow{{AnyDay: true, Start: 0, End: 24 * 60}},
+		},
+		{name: "unknown weekday", expr: "someday 08:00-12:00", wantErr: true},
+		{name: "missing end", expr: "08:00", wantErr: true},
+		{name: "invalid minute", expr: "08:60-09:00", wantErr: true},
+		{name: "after end of day", expr: "23:00-24:01", wantErr: true},
+		{name: "too many fields", expr: "mon tue 08:00-09:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimeWindows(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimeWindows(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTimeWindows(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	// 2024-01-06 is a Saturday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{name: "inside", expr: "04:00-23:30", t: at(6, 12, 0), want: true},
+		{name: "start is inside", expr: "04:00-23:30", t: at(6, 4, 0), want: true},
+		{name: "end is outside", expr: "04:00-23:30", t: at(6, 23, 30), want: false},
+		{name: "other weekday", expr: "sun 08:00-12:00", t: at(6, 9, 0), want: false},
+		{name: "before midnight", expr: "22:00-02:00", t: at(6, 23, 0), want: true},
+		{name: "after midnight", expr: "22:00-02:00", t: at(7, 1, 0), want: true},
+		{name: "after midnight of the previous weekday", expr: "sat 22:00-02:00", t: at(7, 1, 0), want: true},
+		{name: "after midnight of another weekday", expr: "sun 22:00-02:00", t: at(7, 1, 0), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := ParseTimeWindows(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseTimeWindows(%q) error = %v", tt.expr, err)
+			}
+			if got := InTimeWindows(windows, tt.t); got != tt.want {
+				t.Errorf("InTimeWindows(%q, %v) = %v, want %v", tt.expr, tt.t, got, tt.want)
+			}
+		})
+	}
+}
@@ -71,6 +71,7 @@ func SetupRouter() *gin.Engine {
 			ist.GET("/:instance_name", controller.GetInstance)
 			ist.PATCH("/:instance_name", controller.UpdateInstance)
 			ist.DELETE("/:instance_name", controller.DeleteInstance)
+			ist.POST("/:instance_name/task/:task_name/run", controller.RunTask)
 			ist.PATCH("/order", controller.UpdateInstanceOrder)
 		}
 
@@ -86,6 +87,15 @@ func SetupRouter() *gin.Engine {
 			scheduler.PATCH("/state", controller.UpdateSchedulerState)
 			scheduler.GET("/queue/:instance_name", controller.GetTaskQueue)
 			scheduler.POST("/cron", controller.SetSchedulerCron)
+			scheduler.POST("/cron/validate", controller.ValidateCron)
+			scheduler.GET("/upcoming", controller.GetUpcomingRuns)
+		}
+
+		history := api.Group("/history")
+		{
+			history.GET("", controller.GetRunHistory)
+			history.GET("/:run_id", controller.GetRunRecord)
+			history.GET("/:run_id/log", controller.GetRunLog)
 		}
 
 		api.POST("/app/check-update", controller.CheckAppUpdate)
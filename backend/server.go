@@ -4,6 +4,7 @@ import (
 	"dacapo/backend/controller"
 	"dacapo/backend/model"
 	"dacapo/backend/router"
+	"dacapo/backend/service"
 	"dacapo/backend/utils"
 )
 
@@ -14,12 +15,15 @@ func main() {
 	model.InitDB()
 
 	// Check if the symlink is valid, if not, create it
+	// This also restores links left pointing at an override configuration
 	paths, err := model.GetConfigPaths()
 	if err == nil {
 		for _, path := range paths {
 			utils.CheckLink(path[0], path[1])
 		}
 	}
+	service.CleanupOverrides()
+	go service.CleanupAllRunLogs()
 
 	// Start file watcher for instance configuration files
 	fileWatcher := controller.GetFileWatcher()
@@ -29,6 +33,14 @@ func main() {
 		}
 	}
 
+	// Deal with task processes left behind before any instance can start again
+	service.GetServiceManager().SchedulerService().HandleOrphans()
+
+	// Register cron jobs, they follow later configuration changes
+	service.GetServiceManager().CronService().Start()
+	defer service.GetServiceManager().CronService().Stop()
+	service.GetServiceManager().SchedulerService().ReportInterrupted()
+
 	r := router.SetupRouter()
 	r.Run(":48596")
 }
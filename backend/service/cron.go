@@ -0,0 +1,525 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"dacapo/backend/utils"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Cron entry keys of the global schedules, instance entries use instanceCronKey
+const (
+	cronKeyScheduler  = "scheduler"
+	cronKeyAutoAction = "auto_action"
+	cronKeyInstance   = "instance:"
+)
+
+// cronParser accepts the five standard fields and descriptors such as @daily or @every 2h
+var cronParser = cron.NewParser(
+	cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// errSixFields explains why an expression with a sixth field is refused, tools differ on whether it
+// holds seconds or years, so it is not guessed
+var errSixFields = errors.New(
+	"cron expressions have five fields (minute hour day month weekday), a seconds or year field is not supported, use @every for shorter intervals")
+
+// parseCronSpec parses a cron spec, which may start with a CRON_TZ or TZ prefix
+// Six field expressions are refused with a clear message instead of a bare field count
+func parseCronSpec(spec string) (cron.Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "TZ=") || strings.HasPrefix(fields[0], "CRON_TZ=")) {
+		fields = fields[1:]
+	}
+	if len(fields) == 6 {
+		return nil, errSixFields
+	}
+	return cronParser.Parse(spec)
+}
+
+// CronSpec prefixes a cron expression with CRON_TZ unless the timezone is empty or the expression sets its own
+func CronSpec(cronExpr, timezone string) string {
+	if timezone == "" || strings.HasPrefix(cronExpr, "TZ=") || strings.HasPrefix(cronExpr, "CRON_TZ=") {
+		return cronExpr
+	}
+	return "CRON_TZ=" + timezone + " " + cronExpr
+}
+
+// ParseCron parses a cron expression in a timezone, an empty timezone means local time
+func ParseCron(cronExpr, timezone string) (cron.Schedule, error) {
+	return parseCronSpec(CronSpec(cronExpr, timezone))
+}
+
+// maxUpcomingPerSchedule limits the fire times listed for a single schedule
+const maxUpcomingPerSchedule = 500
+
+// instanceCronKey returns the cron entry key of an instance
+func instanceCronKey(instanceName string) string {
+	return cronKeyInstance + instanceName
+}
+
+// cronEntry is a registered cron job and the expression it was created from
+type cronEntry struct {
+	id       cron.EntryID
+	cronExpr string
+}
+
+// CronService owns all cron entries and keeps them in sync with instance and settings changes
+type CronService struct {
+	schedulerService *SchedulerService
+
+	cron    *cron.Cron
+	entries map[string]cronEntry // Entry key -> registered cron job
+	started bool
+	mu      sync.Mutex
+}
+
+// NewCronService creates a cron service, call Start to register the schedules
+func NewCronService(schedulerService *SchedulerService) *CronService {
+	return &CronService{
+		schedulerService: schedulerService,
+		cron:             cron.New(cron.WithParser(cronParser)),
+		entries:          make(map[string]cronEntry),
+	}
+}
+
+// Start registers all schedules from the database and settings, queues missed runs and starts the cron runner
+func (s *CronService) Start() {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	s.ReloadInstances()
+	s.ReloadSettings()
+	s.catchUp()
+
+	s.mu.Lock()
+	s.started = true
+	s.mu.Unlock()
+	s.cron.Start()
+}
+
+// Stop stops the cron runner, running jobs are not interrupted
+func (s *CronService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		s.cron.Stop()
+		s.started = false
+	}
+}
+
+// ReloadInstances registers the schedules of all instances
+func (s *CronService) ReloadInstances() {
+	instanceNames, err := model.GetAllIstNames()
+	if err != nil {
+		utils.Logger.Error("Failed to get all instances:", err)
+		return
+	}
+	for _, name := range instanceNames {
+		s.SyncInstance(name)
+	}
+}
+
+// SyncInstance adds, replaces or removes the schedule of an instance according to its current configuration
+func (s *CronService) SyncInstance(instanceName string) {
+	var istInfo model.InstanceInfo
+	if err := istInfo.GetByName(instanceName); err != nil {
+		utils.Logger.Errorf("[%s]: Failed to get instance info: %v", instanceName, err)
+		s.RemoveInstance(instanceName)
+		return
+	}
+
+	key := instanceCronKey(instanceName)
+	if !istInfo.Ready || istInfo.CronExpr == "" {
+		s.removeEntry(key)
+		return
+	}
+
+	// Random delay spreads the start within the jitter after the cron time
+	jitter := time.Duration(istInfo.CronJitter) * time.Minute
+	if err := s.setEntry(key, CronSpec(istInfo.CronExpr, istInfo.Timezone), func() {
+		if jitter > 0 {
+			delay := rand.N(jitter)
+			utils.Logger.Infof("[%s]: Delaying scheduled start by %v", instanceName, delay.Round(time.Second))
+			time.Sleep(delay)
+		}
+		s.schedulerService.StartOneScheduled(instanceName)
+	}); err != nil {
+		utils.Logger.Errorf("[%s]: Failed to add cron job: %v", instanceName, err)
+	}
+}
+
+// RemoveInstance removes the schedule of a deleted instance
+func (s *CronService) RemoveInstance(instanceName string) {
+	key := instanceCronKey(instanceName)
+	s.removeEntry(key)
+	if err := model.DeleteCronRecord(key); err != nil {
+		utils.Logger.Errorf("[%s]: Failed to delete cron record: %v", instanceName, err)
+	}
+}
+
+// ValidateCron checks a cron expression of the settings, an empty expression disables the schedule
+func ValidateCron(cronExpr string) error {
+	if cronExpr == "" {
+		return nil
+	}
+	_, err := ParseCron(cronExpr, "")
+	return err
+}
+
+// SetSchedulerCron saves the schedule that starts all instances to the settings and applies it
+// The settings are the only source of the scheduler cron, so a reload keeps the saved expression
+func (s *CronService) SetSchedulerCron(cronExpr string) error {
+	if err := ValidateCron(cronExpr); err != nil {
+		return err
+	}
+	if err := model.UpdateSettings(&model.ReqUpdateSettings{SchedulerCron: &cronExpr}); err != nil {
+		return err
+	}
+	s.ReloadSettings()
+	return nil
+}
+
+// applySchedulerCron replaces the schedule that starts all instances, an empty expression removes it
+func (s *CronService) applySchedulerCron(cronExpr string) error {
+	scheduler := model.GetScheduler()
+	scheduler.CronExpr = cronExpr
+
+	if cronExpr == "" {
+		s.removeEntry(cronKeyScheduler)
+		return nil
+	}
+
+	if err := s.setEntry(cronKeyScheduler, cronExpr, func() {
+		scheduler.AutoClose = true
+		s.schedulerService.StartAllScheduled()
+	}); err != nil {
+		utils.Logger.Errorf("Scheduler failed to add cron job: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ReloadSettings applies the scheduler cron and auto action settings
+func (s *CronService) ReloadSettings() {
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Error("Failed to load settings:", err)
+	}
+
+	// Run on startup replaces the scheduler cron
+	schedulerCron := settings.SchedulerCron
+	if settings.RunOnStartup {
+		schedulerCron = ""
+	}
+	s.applySchedulerCron(schedulerCron)
+
+	// Auto close
+	var closeFunc func()
+	switch settings.AutoActionType {
+	case "close_app":
+		closeFunc = utils.CloseApp
+	case "hibernate":
+		closeFunc = utils.Hibernate
+	case "shutdown":
+		closeFunc = utils.Shutdown
+	}
+
+	scheduler := model.GetScheduler()
+	scheduler.CloseFunc = nil
+	if settings.AutoActionTrigger == "scheduled" && settings.AutoActionCron != "" && closeFunc != nil {
+		if err := s.setEntry(cronKeyAutoAction, settings.AutoActionCron, func() {
+			if scheduler.AutoClose {
+				closeFunc()
+			}
+		}); err != nil {
+			utils.Logger.Errorf("Failed to add auto close cron job: %v", err)
+		}
+		return
+	}
+
+	s.removeEntry(cronKeyAutoAction)
+	if settings.AutoActionTrigger == "scheduler_end" {
+		scheduler.CloseFunc = closeFunc
+	}
+}
+
+// setEntry registers a job under a key, replacing any previous entry of the key
+// The previous entry is removed even if the new expression is invalid
+func (s *CronService) setEntry(key, cronExpr string, job func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, replaced := s.entries[key]
+	if replaced {
+		s.cron.Remove(previous.id)
+		delete(s.entries, key)
+	}
+
+	schedule, err := parseCronSpec(cronExpr)
+	if err != nil {
+		return err
+	}
+	entryID := s.cron.Schedule(schedule, cron.FuncJob(func() {
+		if err := model.SaveCronLastFire(key, time.Now()); err != nil {
+			utils.Logger.Errorf("Failed to save last fire time of cron job %s: %v", key, err)
+		}
+		job()
+	}))
+
+	// Missed runs are counted from the last fire time, a new or changed schedule starts counting now
+	// On startup the stored time is kept so that catchUp can find the runs missed while closed
+	_, recorded, err := model.GetCronLastFire(key)
+	if err != nil {
+		utils.Logger.Errorf("Failed to get last fire time of cron job %s: %v", key, err)
+	} else if !recorded || (s.started && previous.cronExpr != cronExpr) {
+		if err := model.SaveCronLastFire(key, time.Now()); err != nil {
+			utils.Logger.Errorf("Failed to save last fire time of cron job %s: %v", key, err)
+		}
+	}
+	s.entries[key] = cronEntry{id: entryID, cronExpr: cronExpr}
+
+	entry := s.cron.Entry(entryID)
+	nextRun := entry.Schedule.Next(time.Now()).Format("2006-01-02 15:04:05")
+	utils.Logger.Infof("Cron job %s set: %s, next run at %s", key, cronExpr, nextRun)
+	return nil
+}
+
+// removeEntry removes the entry of a key if it is registered
+func (s *CronService) removeEntry(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		s.cron.Remove(entry.id)
+		delete(s.entries, key)
+		utils.Logger.Infof("Cron job %s removed", key)
+	}
+}
+
+// catchUp queues the scheduled runs missed while the app was not running, according to each schedule's policy
+func (s *CronService) catchUp() {
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Error("Failed to load settings:", err)
+	}
+	if settings.CatchUpWindow <= 0 {
+		return
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-time.Duration(settings.CatchUpWindow) * time.Hour)
+
+	s.mu.Lock()
+	schedules := make(map[string]cron.Schedule, len(s.entries))
+	for key, entry := range s.entries {
+		schedules[key] = s.cron.Entry(entry.id).Schedule
+	}
+	s.mu.Unlock()
+
+	for key, schedule := range schedules {
+		// Power actions are never caught up, running them right after startup would be unexpected
+		policy := model.CatchUpSkip
+		instanceName, isInstance := strings.CutPrefix(key, cronKeyInstance)
+		if key == cronKeyScheduler {
+			policy = settings.SchedulerCatchUp
+		} else if isInstance {
+			var istInfo model.InstanceInfo
+			if err := istInfo.GetByName(instanceName); err != nil {
+				utils.Logger.Errorf("[%s]: Failed to get instance info: %v", instanceName, err)
+				continue
+			}
+			policy = istInfo.CatchUp
+		}
+
+		lastFire, recorded, err := model.GetCronLastFire(key)
+		if err != nil || !recorded || schedule == nil {
+			continue
+		}
+
+		// Count the fire times between the last run and now, limited to the catch-up window
+		from := lastFire
+		if from.Before(windowStart) {
+			from = windowStart
+		}
+		missed := missedRuns(schedule, from, now)
+		if missed == 0 {
+			continue
+		}
+
+		if err := model.SaveCronLastFire(key, now); err != nil {
+			utils.Logger.Errorf("Failed to save last fire time of cron job %s: %v", key, err)
+		}
+
+		runs := 0
+		switch policy {
+		case model.CatchUpOnce:
+			runs = 1
+		case model.CatchUpAll:
+			runs = missed
+		}
+		utils.Logger.Infof("Cron job %s missed %d runs since %s, policy: %s, queuing %d runs",
+			key, missed, lastFire.Format("2006-01-02 15:04:05"), policy, runs)
+		if runs == 0 {
+			continue
+		}
+
+		if isInstance {
+			go func() {
+				for range runs {
+					s.schedulerService.StartOneScheduled(instanceName)
+				}
+			}()
+		} else if key == cronKeyScheduler {
+			go s.catchUpScheduler(runs)
+		}
+	}
+}
+
+// missedRuns counts the fire times of a schedule after from and up to now
+func missedRuns(schedule cron.Schedule, from, now time.Time) int {
+	missed := 0
+	for next := schedule.Next(from); !next.IsZero() && !next.After(now) && missed < maxUpcomingPerSchedule; next = schedule.Next(next) {
+		missed++
+	}
+	return missed
+}
+
+// catchUpScheduler starts all instances the given number of times, waiting for each run to finish
+func (s *CronService) catchUpScheduler(runs int) {
+	scheduler := model.GetScheduler()
+	for range runs {
+		for scheduler.IsRunning {
+			time.Sleep(time.Second)
+		}
+		scheduler.AutoClose = true
+		s.schedulerService.StartAllScheduled()
+	}
+}
+
+// Upcoming lists the fire times of all registered schedules between now and until, in time order
+func (s *CronService) Upcoming(until time.Time) []model.RspUpcomingRun {
+	plans := s.runPlans()
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := make([]model.RspUpcomingRun, 0)
+	for key, entry := range s.entries {
+		run := model.RspUpcomingRun{
+			CronExpr:  entry.cronExpr,
+			Instances: make([]model.RspUpcomingInstance, 0),
+		}
+		switch {
+		case key == cronKeyScheduler:
+			// StartAll runs every ready instance that has not failed
+			run.Schedule = "scheduler"
+			for _, plan := range plans {
+				if plan.ready {
+					run.Instances = append(run.Instances, plan.instance)
+				}
+			}
+		case key == cronKeyAutoAction:
+			run.Schedule = "auto_action"
+			if settings, err := model.LoadSettings(); err == nil {
+				run.Action = settings.AutoActionType
+			}
+		case strings.HasPrefix(key, cronKeyInstance):
+			run.Schedule = "instance"
+			name := strings.TrimPrefix(key, cronKeyInstance)
+			for _, plan := range plans {
+				if plan.instance.Name == name {
+					run.Instances = append(run.Instances, plan.instance)
+					run.Jitter = plan.jitter
+				}
+			}
+		}
+
+		schedule := s.cron.Entry(entry.id).Schedule
+		if schedule == nil {
+			continue
+		}
+		next := schedule.Next(now)
+		for i := 0; i < maxUpcomingPerSchedule && !next.IsZero() && !next.After(until); i++ {
+			run.Time = next
+			runs = append(runs, run)
+			next = schedule.Next(next)
+		}
+	}
+
+	slices.SortStableFunc(runs, func(a, b model.RspUpcomingRun) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Schedule, b.Schedule)
+	})
+	return runs
+}
+
+// runPlan describes what a scheduled start of an instance would run
+type runPlan struct {
+	instance model.RspUpcomingInstance
+	ready    bool // Included when the scheduler starts all instances
+	jitter   uint // Random start delay of the instance schedule in minutes
+}
+
+// runPlans collects the current task queue of every instance in execution order
+func (s *CronService) runPlans() []runPlan {
+	var instances []model.InstanceInfo
+	if err := model.GetAllInstances(&instances); err != nil {
+		utils.Logger.Error("Failed to get all instances:", err)
+		return nil
+	}
+
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Warn("Failed to load settings:", err)
+	}
+
+	scheduler := model.GetScheduler()
+	plans := make([]runPlan, 0, len(instances))
+	for _, ist := range instances {
+		// The instance list does not include the tasks
+		var istInfo model.InstanceInfo
+		if err := istInfo.GetByName(ist.Name); err != nil {
+			utils.Logger.Errorf("[%s]: Failed to get instance info: %v", ist.Name, err)
+			continue
+		}
+
+		plan := runPlan{
+			instance: model.RspUpcomingInstance{
+				Name:       ist.Name,
+				Background: ist.Background,
+				Tasks:      make([]string, 0),
+			},
+			ready:  ist.Ready,
+			jitter: ist.CronJitter,
+		}
+		if tm := scheduler.GetTaskManager(ist.Name); tm != nil {
+			// A scheduled run starts from the saved task queue, unless a queue edited by hand is kept
+			if settings.QueueRebuild == model.QueueKeepEdits && tm.QueueEdited {
+				plan.instance.Tasks = append(plan.instance.Tasks, tm.QueueSnapshot().Waiting...)
+			} else {
+				waiting, _ := istInfo.GetTaskQueue()
+				plan.instance.Tasks = append(plan.instance.Tasks, waiting...)
+			}
+			plan.ready = plan.ready && tm.Status != model.StatusFailed
+		} else {
+			plan.ready = false
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetEntryInvalidExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		cronExpr string
+	}{
+		{name: "empty", cronExpr: ""},
+		{name: "too few fields", cronExpr: "0 18 *"},
+		{name: "hour out of range", cronExpr: "0 25 * * *"},
+		{name: "unknown descriptor", cronExpr: "@fortnightly"},
+		{name: "unknown timezone", cronExpr: CronSpec("0 18 * * *", "Nowhere/City")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewCronService(nil)
+			key := instanceCronKey("game-a")
+			id, err := s.cron.AddFunc("0 6 * * *", func() {})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.entries[key] = cronEntry{id: id, cronExpr: "0 6 * * *"}
+
+			if err := s.setEntry(key, tt.cronExpr, func() {}); err == nil {
+				t.Fatalf("setEntry(%q) error = nil, want an error", tt.cronExpr)
+			}
+			// The previous schedule no longer applies once the configuration has changed
+			if _, ok := s.entries[key]; ok {
+				t.Errorf("entry %s is still registered", key)
+			}
+			if entries := s.cron.Entries(); len(entries) != 0 {
+				t.Errorf("cron has %d entries, want 0", len(entries))
+			}
+		})
+	}
+}
+
+func TestValidateCron(t *testing.T) {
+	for _, cronExpr := range []string{"", "0 18 * * *", "@daily", CronSpec("30 6 * * 1-5", "Asia/Tokyo")} {
+		if err := ValidateCron(cronExpr); err != nil {
+			t.Errorf("ValidateCron(%q) error = %v", cronExpr, err)
+		}
+	}
+	for _, cronExpr := range []string{"0 25 * * *", "every day", "* * *"} {
+		if err := ValidateCron(cronExpr); err == nil {
+			t.Errorf("ValidateCron(%q) error = nil, want an error", cronExpr)
+		}
+	}
+}
+
+func TestParseCronRefusesSixFields(t *testing.T) {
+	// Read as seconds by some tools and as years by others
+	for _, spec := range []string{"0 0 18 * * *", "0 18 * * * 2025"} {
+		if _, err := ParseCron(spec, "Asia/Tokyo"); !errors.Is(err, errSixFields) {
+			t.Errorf("ParseCron(%q) error = %v, want %v", spec, err, errSixFields)
+		}
+	}
+
+	// The cron service uses the same parser, so a stored six field expression is not scheduled either
+	s := NewCronService(nil)
+	if err := s.setEntry(instanceCronKey("game-a"), "0 0 18 * * *", func() {}); err == nil {
+		t.Error("setEntry() accepted a six field expression")
+	}
+
+	if _, err := ParseCron("0 18 * * *", "Asia/Tokyo"); err != nil {
+		t.Errorf("ParseCron() error = %v for a five field expression", err)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	useTestDB(t)
+	s := NewCronService(nil)
+	if err := s.setEntry(cronKeyScheduler, "0 * * * *", func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.setEntry(instanceCronKey("game-a"), "*/20 * * * *", func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	runs := s.Upcoming(now.Add(3 * time.Hour))
+
+	counts := make(map[string]int)
+	for i, run := range runs {
+		counts[run.Schedule]++
+		if !run.Time.After(now) || run.Time.After(now.Add(3*time.Hour)) {
+			t.Errorf("run %d at %v is outside the requested range", i, run.Time)
+		}
+		if i > 0 && run.Time.Before(runs[i-1].Time) {
+			t.Errorf("run %d at %v is listed after %v", i, run.Time, runs[i-1].Time)
+		}
+	}
+	if counts["scheduler"] != 3 || counts["instance"] != 9 {
+		t.Errorf("got %d scheduler and %d instance runs, want 3 and 9", counts["scheduler"], counts["instance"])
+	}
+	// Instances that do not exist have no queue to show
+	for _, run := range runs {
+		if len(run.Instances) != 0 {
+			t.Errorf("run %+v lists instances", run)
+			break
+		}
+	}
+
+	// A frequent schedule over a long range is cut off
+	s.setEntry(instanceCronKey("game-a"), "* * * * *", func() {})
+	counts = make(map[string]int)
+	for _, run := range s.Upcoming(now.AddDate(1, 0, 0)) {
+		counts[run.Schedule]++
+	}
+	if counts["instance"] != maxUpcomingPerSchedule {
+		t.Errorf("got %d runs of a schedule firing every minute, want %d", counts["instance"], maxUpcomingPerSchedule)
+	}
+}
+
+func TestMissedRuns(t *testing.T) {
+	hourly, err := ParseCron("0 * * * *", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 6, 1, 10, 30, 0, 0, time.Local)
+
+	if got := missedRuns(hourly, from, from.Add(4*time.Hour+30*time.Minute)); got != 5 {
+		t.Errorf("missedRuns() from 10:30 to 15:00 = %d, want 5", got)
+	}
+	if got := missedRuns(hourly, from, from.Add(20*time.Minute)); got != 0 {
+		t.Errorf("missedRuns() from 10:30 to 10:50 = %d, want 0", got)
+	}
+
+	everyMinute, _ := ParseCron("* * * * *", "")
+	if got := missedRuns(everyMinute, from, from.AddDate(0, 1, 0)); got != maxUpcomingPerSchedule {
+		t.Errorf("missedRuns() over a month of minutes = %d, want %d", got, maxUpcomingPerSchedule)
+	}
+}
+
+func TestCatchUpRecordsMissedRuns(t *testing.T) {
+	useTestDB(t)
+	window, skip := 2, model.CatchUpSkip
+	if err := model.UpdateSettings(&model.ReqUpdateSettings{CatchUpWindow: &window, SchedulerCatchUp: &skip}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewCronService(nil)
+	s.setEntry(cronKeyAutoAction, "0 * * * *", func() {})
+	s.setEntry(cronKeyScheduler, "0 0 1 1 *", func() {})
+	now := time.Now()
+	model.SaveCronLastFire(cronKeyAutoAction, now.Add(-5*time.Hour))
+	model.SaveCronLastFire(cronKeyScheduler, now.Add(-time.Minute))
+
+	s.catchUp()
+
+	// Missed runs are handled once, the next startup counts from now
+	lastFire, _, _ := model.GetCronLastFire(cronKeyAutoAction)
+	if lastFire.Before(now) {
+		t.Errorf("last fire of the auto action = %v, want it moved to the catch-up time", lastFire)
+	}
+	lastFire, _, _ = model.GetCronLastFire(cronKeyScheduler)
+	if !lastFire.Equal(now.Add(-time.Minute)) {
+		t.Errorf("last fire of the scheduler = %v, want it unchanged without missed runs", lastFire)
+	}
+}
@@ -319,6 +319,29 @@ func (s *InstanceService) BuildLayout(istInfo *model.InstanceInfo, istConf *mode
 	}
 	groupGeneralBase.Set("background", itemBackground)
 
+	itemResources := model.ItemConf{
+		Type:     "input",
+		Value:    istInfo.Resources,
+		Disabled: istInfo.ResourcesDisabled,
+	}
+	groupGeneralBase.Set("resources", itemResources)
+
+	itemDependsOn := model.ItemConf{
+		Type:  "input",
+		Value: istInfo.DependsOn,
+	}
+	groupGeneralBase.Set("depends_on", itemDependsOn)
+
+	itemDependencyPolicy := model.ItemConf{
+		Type:  "select",
+		Value: istInfo.DependencyPolicy,
+		Option: []any{
+			model.DependencySkip,
+			model.DependencyRun,
+		},
+	}
+	groupGeneralBase.Set("dependency_policy", itemDependencyPolicy)
+
 	itemConfigPath := model.ItemConf{
 		Type:     "folder",
 		Value:    istInfo.ConfigPath,
@@ -339,6 +362,62 @@ func (s *InstanceService) BuildLayout(istInfo *model.InstanceInfo, istConf *mode
 	}
 	groupGeneralBase.Set("cron_expr", itemCronExpr)
 
+	itemTimezone := model.ItemConf{
+		Type:  "input",
+		Value: istInfo.Timezone,
+	}
+	groupGeneralBase.Set("timezone", itemTimezone)
+
+	itemCronJitter := model.ItemConf{
+		Type:  "number",
+		Value: istInfo.CronJitter,
+	}
+	groupGeneralBase.Set("cron_jitter", itemCronJitter)
+
+	itemCatchUp := model.ItemConf{
+		Type:  "select",
+		Value: istInfo.CatchUp,
+		Option: []any{
+			model.CatchUpOnce,
+			model.CatchUpAll,
+			model.CatchUpSkip,
+		},
+	}
+	groupGeneralBase.Set("catch_up", itemCatchUp)
+
+	itemExecWindow := model.ItemConf{
+		Type:  "input",
+		Value: istInfo.ExecWindow,
+	}
+	groupGeneralBase.Set("exec_window", itemExecWindow)
+
+	itemWindowPolicy := model.ItemConf{
+		Type:  "select",
+		Value: istInfo.WindowPolicy,
+		Option: []any{
+			model.WindowPolicyFinish,
+			model.WindowPolicyStop,
+		},
+	}
+	groupGeneralBase.Set("window_policy", itemWindowPolicy)
+
+	itemTaskTimeout := model.ItemConf{
+		Type:  "number",
+		Value: istInfo.TaskTimeout,
+	}
+	groupGeneralBase.Set("task_timeout", itemTaskTimeout)
+
+	itemOnFailure := model.ItemConf{
+		Type:  "select",
+		Value: istInfo.OnFailure,
+		Option: []any{
+			model.FailureStopInstance,
+			model.FailureContinue,
+			model.FailureRetryThenContinue,
+		},
+	}
+	groupGeneralBase.Set("on_failure", itemOnFailure)
+
 	// Custom settings from template configuration file
 	if tplMenuProject, ok := tplConf.OM.Get("Project"); ok {
 		if tplTaskGeneral, ok := tplMenuProject.Get("General"); ok {
@@ -490,6 +569,87 @@ func (s *InstanceService) BuildLayout(istInfo *model.InstanceInfo, istConf *mode
 			}
 			newGroupBase.Set("command", itemCommand)
 
+			itemTimeout := model.ItemConf{
+				Type:     "number",
+				Value:    taskInfo.Timeout,
+				Disabled: taskInfo.TimeoutDisabled,
+			}
+			newGroupBase.Set("timeout", itemTimeout)
+
+			itemResources := model.ItemConf{
+				Type:     "input",
+				Value:    taskInfo.Resources,
+				Disabled: taskInfo.ResourcesDisabled,
+			}
+			newGroupBase.Set("resources", itemResources)
+
+			itemDependsOn := model.ItemConf{
+				Type:     "input",
+				Value:    taskInfo.DependsOn,
+				Disabled: taskInfo.DependsOnDisabled,
+			}
+			newGroupBase.Set("depends_on", itemDependsOn)
+
+			itemRunIfFailed := model.ItemConf{
+				Type:     "checkbox",
+				Value:    taskInfo.RunIfFailed,
+				Disabled: taskInfo.RunIfFailedDisabled,
+			}
+			newGroupBase.Set("run_if_failed", itemRunIfFailed)
+
+			itemAlways := model.ItemConf{
+				Type:     "checkbox",
+				Value:    taskInfo.Always,
+				Disabled: taskInfo.AlwaysDisabled,
+			}
+			newGroupBase.Set("always", itemAlways)
+
+			itemMaxRetries := model.ItemConf{
+				Type:     "number",
+				Value:    taskInfo.MaxRetries,
+				Disabled: taskInfo.MaxRetriesDisabled,
+			}
+			newGroupBase.Set("max_retries", itemMaxRetries)
+
+			itemRetryDelay := model.ItemConf{
+				Type:     "number",
+				Value:    taskInfo.RetryDelay,
+				Disabled: taskInfo.RetryDelayDisabled,
+			}
+			newGroupBase.Set("retry_delay", itemRetryDelay)
+
+			itemRetryBackoff := model.ItemConf{
+				Type:     "select",
+				Value:    taskInfo.RetryBackoff,
+				Disabled: taskInfo.RetryBackoffDisabled,
+				Option: []any{
+					model.BackoffFixed,
+					model.BackoffLinear,
+					model.BackoffExponential,
+				},
+			}
+			newGroupBase.Set("retry_backoff", itemRetryBackoff)
+
+			itemOnFailure := model.ItemConf{
+				Type:     "select",
+				Value:    taskInfo.OnFailure,
+				Disabled: taskInfo.OnFailureDisabled,
+				Option: []any{
+					model.FailureDefault,
+					model.FailureStopInstance,
+					model.FailureContinue,
+					model.FailureRetryThenContinue,
+				},
+			}
+			newGroupBase.Set("on_failure", itemOnFailure)
+
+			itemExitCodes := model.ItemConf{
+				Type:     "input",
+				Value:    taskInfo.ExitCodes,
+				Disabled: taskInfo.ExitCodesDisabled,
+			}
+			newGroupBase.Set("exit_codes", itemExitCodes)
+
 			// Custom settings from template configuration file
 			for pair := taskConf.Oldest(); pair != nil; pair = pair.Next() {
 				groupName := pair.Key
@@ -30,7 +30,7 @@ func (s *InstanceUpdaterService) UpdateRepo(instanceName string) (model.RspUpdat
 	tm := scheduler.GetTaskManager(instanceName)
 
 	// Check if instance is running
-	if tm != nil && tm.Status == model.StatusRunning {
+	if tm != nil && tm.IsBusy() {
 		errMsg := "cannot update - instance is running"
 		utils.Logger.Warnf("[%s]: %s", instanceName, errMsg)
 		return model.RspUpdateRepo{
@@ -168,7 +168,7 @@ func (s *InstanceUpdaterService) createEnv(tm *model.TaskManager, envPath string
 			utils.Logger.Warnf("[%s]: uv not found or python version not set, using default python command to create venv: %s", tm.InstanceName, cmd)
 		}
 
-		if err := s.schedulerService.RunCommand(tm, cmd, ""); err != nil {
+		if err := s.schedulerService.RunCommand(tm, cmd, "", RunOptions{}); err != nil {
 			return err
 		}
 	}
@@ -273,7 +273,7 @@ func (s *InstanceUpdaterService) installDeps(tm *model.TaskManager, envPath, dep
 		utils.Logger.Infof("[%s]: Installing dependencies with pip: %s", tm.InstanceName, cmd)
 	}
 
-	if err = s.schedulerService.RunCommand(tm, cmd, ""); err != nil {
+	if err = s.schedulerService.RunCommand(tm, cmd, "", RunOptions{}); err != nil {
 		return err
 	}
 
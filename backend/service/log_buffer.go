@@ -0,0 +1,44 @@
+package service
+
+import "sync"
+
+// LogBufferSize is the number of recent log lines kept for each instance
+const LogBufferSize = 1000
+
+// LogBuffer is a bounded ring buffer of recent log lines
+type LogBuffer struct {
+	lines []string
+	start int // Index of the oldest line once the buffer is full
+	mu    sync.Mutex
+}
+
+// NewLogBuffer creates a ring buffer holding up to size lines
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{
+		lines: make([]string, 0, size),
+	}
+}
+
+// Add appends a line, overwriting the oldest one when the buffer is full
+func (b *LogBuffer) Add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.lines) < cap(b.lines) {
+		b.lines = append(b.lines, line)
+		return
+	}
+	b.lines[b.start] = line
+	b.start = (b.start + 1) % len(b.lines)
+}
+
+// Lines returns a copy of the buffered lines from oldest to newest
+func (b *LogBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := make([]string, 0, len(b.lines))
+	result = append(result, b.lines[b.start:]...)
+	result = append(result, b.lines[:b.start]...)
+	return result
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLogBufferKeepsNewestLines(t *testing.T) {
+	buffer := NewLogBuffer(3)
+	if got := buffer.Lines(); len(got) != 0 {
+		t.Fatalf("Lines() of an empty buffer = %v", got)
+	}
+
+	buffer.Add("one")
+	buffer.Add("two")
+	if got, want := buffer.Lines(), []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %v, want %v", got, want)
+	}
+
+	// Wrap around more than once
+	for _, line := range []string{"three", "four", "five", "six", "seven"} {
+		buffer.Add(line)
+	}
+	if got, want := buffer.Lines(), []string{"five", "six", "seven"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %v, want %v", got, want)
+	}
+
+	// Callers may keep the result while lines are still added
+	lines := buffer.Lines()
+	lines[0] = "changed"
+	if buffer.Lines()[0] != "five" {
+		t.Error("Lines() returned the internal slice")
+	}
+}
+
+func TestLogBufferConcurrentAdd(t *testing.T) {
+	buffer := NewLogBuffer(LogBufferSize)
+	var wg sync.WaitGroup
+	for worker := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range LogBufferSize {
+				buffer.Add(fmt.Sprintf("%d-%d", worker, i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(buffer.Lines()); got != LogBufferSize {
+		t.Errorf("len(Lines()) = %d, want %d", got, LogBufferSize)
+	}
+}
@@ -69,6 +69,15 @@ func (n *NotificationService) buildContent(result *model.SchedulerResult) string
 	builder.WriteString(fmt.Sprintf("- **总实例数**: %d\n", result.TotalCount))
 	builder.WriteString(fmt.Sprintf("- **成功**: %d\n", result.SuccessCount))
 	builder.WriteString(fmt.Sprintf("- **失败**: %d\n", result.FailedCount))
+	if timeoutCount := n.countTimeouts(result); timeoutCount > 0 {
+		builder.WriteString(fmt.Sprintf("- **超时**: %d\n", timeoutCount))
+	}
+	if result.SkippedCount > 0 {
+		builder.WriteString(fmt.Sprintf("- **跳过**: %d\n", result.SkippedCount))
+	}
+	if partialCount := n.countPartialSuccess(result); partialCount > 0 {
+		builder.WriteString(fmt.Sprintf("- **部分成功**: %d\n", partialCount))
+	}
 	builder.WriteString("\n---\n\n")
 
 	// Success instances
@@ -76,7 +85,15 @@ func (n *NotificationService) buildContent(result *model.SchedulerResult) string
 		builder.WriteString("## ✅ 成功实例\n\n")
 		for _, r := range result.Results {
 			if r.Success {
-				builder.WriteString(fmt.Sprintf("- **%s**\n", r.Name))
+				line := fmt.Sprintf("- **%s**", r.Name)
+				if r.Retries > 0 {
+					line += fmt.Sprintf(" (重试%d次)", r.Retries)
+				}
+				if skipped := r.SkippedTasks(); skipped > 0 {
+					line += fmt.Sprintf(" (跳过%d个任务)", skipped)
+				}
+				line += n.dependsOnSuffix(r)
+				builder.WriteString(line + "\n")
 			}
 		}
 		builder.WriteString("\n")
@@ -88,11 +105,25 @@ func (n *NotificationService) buildContent(result *model.SchedulerResult) string
 		for _, r := range result.Results {
 			if !r.Success {
 				// Show instance name and task name if available
+				heading := r.Name
 				if r.TaskName != "" {
-					builder.WriteString(fmt.Sprintf("### %s - 任务: %s\n\n", r.Name, r.TaskName))
-				} else {
-					builder.WriteString(fmt.Sprintf("### %s\n\n", r.Name))
+					heading = fmt.Sprintf("%s - 任务: %s", r.Name, r.TaskName)
+				}
+				if r.Skipped {
+					heading += " (跳过)"
 				}
+				if r.TimedOut {
+					heading += " (超时)"
+				}
+				if r.Retries > 0 {
+					heading += fmt.Sprintf(" (重试%d次)", r.Retries)
+				}
+				if succeeded := r.SucceededTasks(); succeeded > 0 {
+					heading += fmt.Sprintf(" (部分成功 %d/%d)", succeeded, len(r.Tasks))
+				}
+				heading += n.dependsOnSuffix(r)
+				builder.WriteString(fmt.Sprintf("### %s\n\n", heading))
+				n.writeTaskResults(&builder, r.Tasks)
 				builder.WriteString("```\n")
 				if r.Error != "" {
 					builder.WriteString(r.Error)
@@ -106,3 +137,57 @@ func (n *NotificationService) buildContent(result *model.SchedulerResult) string
 
 	return builder.String()
 }
+
+// dependsOnSuffix lists the instances a result depended on
+func (n *NotificationService) dependsOnSuffix(r model.InstanceResult) string {
+	if len(r.DependsOn) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (依赖: %s)", strings.Join(r.DependsOn, "、"))
+}
+
+// writeTaskResults lists the outcome of every task of an instance
+func (n *NotificationService) writeTaskResults(builder *strings.Builder, tasks []model.TaskResult) {
+	if len(tasks) == 0 {
+		return
+	}
+	for _, task := range tasks {
+		switch {
+		case task.Success:
+			builder.WriteString(fmt.Sprintf("- ✅ %s", task.Name))
+		case task.Skipped:
+			builder.WriteString(fmt.Sprintf("- ⏭️ %s (跳过)", task.Name))
+		case task.TimedOut:
+			builder.WriteString(fmt.Sprintf("- ❌ %s (超时)", task.Name))
+		default:
+			builder.WriteString(fmt.Sprintf("- ❌ %s", task.Name))
+		}
+		if task.Retries > 0 {
+			builder.WriteString(fmt.Sprintf(" (重试%d次)", task.Retries))
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString("\n")
+}
+
+// countPartialSuccess counts the failed instances in which some tasks succeeded
+func (n *NotificationService) countPartialSuccess(result *model.SchedulerResult) int {
+	count := 0
+	for _, r := range result.Results {
+		if !r.Success && r.SucceededTasks() > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// countTimeouts counts the instances that failed because a task timed out
+func (n *NotificationService) countTimeouts(result *model.SchedulerResult) int {
+	count := 0
+	for _, r := range result.Results {
+		if r.TimedOut {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"dacapo/backend/utils"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// OrphanCheckInterval is how often an adopted process is checked for having exited
+const OrphanCheckInterval = 2 * time.Second
+
+// recordProcess stores the process of a started task, returning nil if it could not be stored
+func recordProcess(instanceName, taskName string, pid int) *model.ChildProcess {
+	startTime, err := utils.ProcessStartTime(pid)
+	if err != nil {
+		startTime = time.Now()
+	}
+
+	record := &model.ChildProcess{}
+	if err := record.Create(instanceName, taskName, pid, startTime); err != nil {
+		utils.Logger.Warnf("[%s]: Failed to record process %d: %v", instanceName, pid, err)
+		return nil
+	}
+	return record
+}
+
+// forgetProcess removes the record of a task process that has exited
+func forgetProcess(record *model.ChildProcess) {
+	if record == nil {
+		return
+	}
+	if err := record.Delete(); err != nil {
+		utils.Logger.Warnf("[%s]: Failed to remove process record: %v", record.InstanceName, err)
+	}
+}
+
+// isAlive reports whether the recorded process is still running
+// A different start time means that the PID has been reused by another process
+func isAlive(record *model.ChildProcess) bool {
+	startTime, err := utils.ProcessStartTime(record.PID)
+	if err != nil {
+		return false
+	}
+	diff := startTime.Sub(record.StartTime)
+	return diff > -time.Second && diff < time.Second
+}
+
+// HandleOrphans deals with task processes that kept running after the app last stopped unexpectedly
+// Depending on the orphan policy they are adopted by their instance, killed or only reported
+func (s *SchedulerService) HandleOrphans() {
+	records, err := model.GetChildProcesses()
+	if err != nil {
+		utils.Logger.Error("Failed to get process records:", err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Warn("Failed to load settings:", err)
+	}
+
+	for i := range records {
+		record := &records[i]
+		if !isAlive(record) {
+			forgetProcess(record)
+			continue
+		}
+
+		utils.Logger.Warnf("[%s]: Process %d of task %s is still running from the last session",
+			record.InstanceName, record.PID, record.TaskName)
+		switch settings.OrphanPolicy {
+		case model.OrphanKill:
+			s.killOrphan(record, time.Duration(settings.StopGracePeriod)*time.Second)
+		case model.OrphanAdopt:
+			if err := s.adoptOrphan(record); err != nil {
+				utils.Logger.Warnf("[%s]: Failed to adopt process %d: %v", record.InstanceName, record.PID, err)
+				s.reportOrphan(record)
+			}
+		default:
+			s.reportOrphan(record)
+		}
+	}
+}
+
+// reportOrphan tells the user about a process left running, its record is kept until it has exited
+func (s *SchedulerService) reportOrphan(record *model.ChildProcess) {
+	s.wsService.BroadcastLog(record.InstanceName, fmt.Sprintf(
+		"[DaCapo] Process %d of task %s is still running from the last session and is not managed", record.PID, record.TaskName))
+}
+
+// killOrphan terminates a process left running together with its children
+func (s *SchedulerService) killOrphan(record *model.ChildProcess, grace time.Duration) {
+	if err := utils.TerminateProcessTree(record.PID, grace); err != nil {
+		utils.Logger.Errorf("[%s]: Failed to terminate process %d: %v", record.InstanceName, record.PID, err)
+		s.reportOrphan(record)
+		return
+	}
+	forgetProcess(record)
+	s.wsService.BroadcastLog(record.InstanceName, fmt.Sprintf(
+		"[DaCapo] Terminated process %d of task %s left running from the last session", record.PID, record.TaskName))
+}
+
+// adoptOrphan tracks a process left running as the running task of its instance until it exits
+// Its output and exit code cannot be read anymore, so the task gets no outcome
+func (s *SchedulerService) adoptOrphan(record *model.ChildProcess) error {
+	instanceName := record.InstanceName
+	tm := model.GetScheduler().GetTaskManager(instanceName)
+	if tm == nil {
+		return fmt.Errorf("instance not found: %s", instanceName)
+	}
+	if tm.IsBusy() || tm.Status == model.StatusUpdating {
+		return fmt.Errorf("instance %s is %s", instanceName, tm.Status)
+	}
+
+	resources := s.instanceResources(instanceName)
+	if !s.locks.TryAcquire(resources) {
+		return fmt.Errorf("resources of %s are in use", instanceName)
+	}
+	process, err := os.FindProcess(record.PID)
+	if err != nil {
+		s.locks.Release(resources)
+		return err
+	}
+
+	// The interrupted task goes on, so the run is no longer reported as interrupted
+	tm.LastOutcome = ""
+	tm.LastError = ""
+	// Stop requests reach the process through the command of the task manager
+	tm.Cmd.Store(&exec.Cmd{Process: process})
+	tm.StartTask(record.TaskName)
+	s.UpdateInstanceStatus(instanceName, model.StatusRunning)
+	s.publishQueue(instanceName)
+	s.wsService.BroadcastLog(instanceName, fmt.Sprintf(
+		"[DaCapo] Adopted process %d of task %s left running from the last session", record.PID, record.TaskName))
+
+	go func() {
+		defer s.locks.Release(resources)
+		for isAlive(record) {
+			time.Sleep(OrphanCheckInterval)
+		}
+		forgetProcess(record)
+
+		tm.Cmd.Store(nil)
+		tm.Paused.Store(false)
+		stopped := tm.ManualStop.Swap(false)
+		if stopped {
+			tm.SetTaskState(record.TaskName, model.TaskState{State: model.TaskCancelled, ExitCode: -1})
+		}
+		utils.Logger.Infof("[%s]: Adopted process %d of task %s exited", instanceName, record.PID, record.TaskName)
+		s.wsService.BroadcastLog(instanceName, fmt.Sprintf("[DaCapo] Adopted process of task %s exited", record.TaskName))
+
+		tm.RemoveRun()
+		s.UpdateInstanceStatus(instanceName, model.StatusPending)
+		s.publishQueue(instanceName)
+	}()
+	return nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"sync"
+	"time"
+)
+
+// resourceWaitInterval is how often a blocked Acquire checks whether it should give up
+const resourceWaitInterval = time.Second
+
+// ResourceLocks tracks how many units of each named resource are held
+type ResourceLocks struct {
+	used    map[string]int
+	changed chan struct{} // Closed and replaced whenever resources are released
+	mu      sync.Mutex
+}
+
+// NewResourceLocks creates an empty lock table
+func NewResourceLocks() *ResourceLocks {
+	return &ResourceLocks{
+		used:    make(map[string]int),
+		changed: make(chan struct{}),
+	}
+}
+
+// TryAcquire takes one unit of every resource if all of them are available, otherwise takes none
+func (l *ResourceLocks) TryAcquire(resources []model.Resource) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, resource := range resources {
+		if l.used[resource.Name] >= resource.Capacity {
+			return false
+		}
+	}
+	for _, resource := range resources {
+		l.used[resource.Name]++
+	}
+	return true
+}
+
+// Acquire blocks until all resources are taken, or returns false once stop reports true
+func (l *ResourceLocks) Acquire(resources []model.Resource, stop func() bool) bool {
+	for {
+		changed := l.Changed()
+		if l.TryAcquire(resources) {
+			return true
+		}
+		if stop() {
+			return false
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(resourceWaitInterval):
+		}
+	}
+}
+
+// Release returns one unit of every resource
+func (l *ResourceLocks) Release(resources []model.Resource) {
+	if len(resources) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, resource := range resources {
+		if l.used[resource.Name] > 1 {
+			l.used[resource.Name]--
+		} else {
+			delete(l.used, resource.Name)
+		}
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Changed returns a channel that is closed on the next release
+func (l *ResourceLocks) Changed() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.changed
+}
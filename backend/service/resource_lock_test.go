@@ -0,0 +1,115 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"testing"
+	"time"
+)
+
+func TestResourceLocksTryAcquire(t *testing.T) {
+	gpu := model.Resource{Name: "gpu", Capacity: 2}
+	emulator := model.Resource{Name: "emulator", Capacity: 1}
+
+	tests := []struct {
+		name     string
+		held     [][]model.Resource
+		acquire  []model.Resource
+		want     bool
+		wantUsed map[string]int
+	}{
+		{
+			name:     "free",
+			acquire:  []model.Resource{gpu, emulator},
+			want:     true,
+			wantUsed: map[string]int{"gpu": 1, "emulator": 1},
+		},
+		{
+			name:     "below capacity",
+			held:     [][]model.Resource{{gpu}},
+			acquire:  []model.Resource{gpu},
+			want:     true,
+			wantUsed: map[string]int{"gpu": 2},
+		},
+		{
+			name:     "at capacity",
+			held:     [][]model.Resource{{gpu}, {gpu}},
+			acquire:  []model.Resource{gpu},
+			want:     false,
+			wantUsed: map[string]int{"gpu": 2},
+		},
+		{
+			name:     "all or nothing",
+			held:     [][]model.Resource{{emulator}},
+			acquire:  []model.Resource{gpu, emulator},
+			want:     false,
+			wantUsed: map[string]int{"emulator": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locks := NewResourceLocks()
+			for _, resources := range tt.held {
+				if !locks.TryAcquire(resources) {
+					t.Fatalf("TryAcquire(%v) failed while setting up", resources)
+				}
+			}
+			if got := locks.TryAcquire(tt.acquire); got != tt.want {
+				t.Errorf("TryAcquire(%v) = %v, want %v", tt.acquire, got, tt.want)
+			}
+			for name, want := range tt.wantUsed {
+				if got := locks.used[name]; got != want {
+					t.Errorf("used[%s] = %d, want %d", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceLocksAcquire(t *testing.T) {
+	emulator := []model.Resource{{Name: "emulator", Capacity: 1}}
+	gpu := []model.Resource{{Name: "gpu", Capacity: 1}}
+
+	t.Run("keeps held resources while waiting", func(t *testing.T) {
+		locks := NewResourceLocks()
+		locks.TryAcquire(emulator) // Held by the running instance
+		locks.TryAcquire(gpu)      // Held by another instance
+
+		done := make(chan bool)
+		go func() {
+			done <- locks.Acquire(gpu, func() bool { return false })
+		}()
+
+		// Nobody can take the emulator from the instance in the middle of its run
+		time.Sleep(50 * time.Millisecond)
+		if locks.TryAcquire(emulator) {
+			t.Fatal("emulator was taken while its holder waited for gpu")
+		}
+
+		locks.Release(gpu)
+		select {
+		case ok := <-done:
+			if !ok {
+				t.Fatal("Acquire() = false, want true")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Acquire() did not return after gpu was released")
+		}
+		if locks.used["emulator"] != 1 || locks.used["gpu"] != 1 {
+			t.Errorf("used = %v, want one unit of emulator and gpu", locks.used)
+		}
+	})
+
+	t.Run("stopped while waiting", func(t *testing.T) {
+		locks := NewResourceLocks()
+		locks.TryAcquire(emulator)
+		locks.TryAcquire(gpu)
+
+		if locks.Acquire(gpu, func() bool { return true }) {
+			t.Fatal("Acquire() = true, want false")
+		}
+		if locks.used["emulator"] != 1 || locks.used["gpu"] != 1 {
+			t.Errorf("used = %v, want the units of the previous holders only", locks.used)
+		}
+	})
+}
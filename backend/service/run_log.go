@@ -0,0 +1,171 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"dacapo/backend/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunLogDir is the root directory of archived per-run logs
+const RunLogDir = "logs/runs"
+
+// runLogNameReplacer replaces characters that are not allowed in file names
+var runLogNameReplacer = strings.NewReplacer(
+	"<", "_", ">", "_", ":", "_", "\"", "_", "/", "_", "\\", "_", "|", "_", "?", "_", "*", "_",
+)
+
+// runLogDir returns the directory of the archived logs of an instance
+// Names made of dots only are escaped, so that "." or ".." cannot point outside of RunLogDir
+func runLogDir(instanceName string) string {
+	name := runLogNameReplacer.Replace(instanceName)
+	if strings.Trim(name, ".") == "" {
+		name = strings.Repeat("_", len(name)+1)
+	}
+	return filepath.Join(RunLogDir, name)
+}
+
+// RunLog writes the combined output of a single task run to its own file
+type RunLog struct {
+	Path string
+	file *os.File
+	mu   sync.Mutex // stdout and stderr are written from separate goroutines
+}
+
+// NewRunLog creates the log file for a task run under logs/runs/<instance>/
+func NewRunLog(instanceName, taskName string, start time.Time) (*RunLog, error) {
+	dir := runLogDir(instanceName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// Runs started within the same second get a numeric suffix
+	base := fmt.Sprintf("%s-%s", start.Format("20060102-150405"), runLogNameReplacer.Replace(taskName))
+	path := filepath.Join(dir, base+".log")
+	for i := 1; ; i++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		if err == nil {
+			return &RunLog{Path: path, file: file}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.log", base, i))
+	}
+}
+
+// WriteLine appends a single output line to the log file
+func (l *RunLog) WriteLine(text string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.WriteString(text + "\n"); err != nil {
+		utils.Logger.Warnf("Failed to write run log %s: %v", l.Path, err)
+	}
+}
+
+// Close closes the log file
+func (l *RunLog) Close() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Close(); err != nil {
+		utils.Logger.Warnf("Failed to close run log %s: %v", l.Path, err)
+	}
+}
+
+// CleanupRunLogs removes archived logs of an instance according to the retention settings
+func CleanupRunLogs(instanceName string) {
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Warn("Failed to load settings, skipping run log cleanup")
+		return
+	}
+	if settings.RunLogRetentionDays <= 0 && settings.RunLogMaxFiles <= 0 {
+		return
+	}
+
+	dir := runLogDir(instanceName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	files := make([]runLogFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".log" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, runLogFile{path: filepath.Join(dir, entry.Name()), modTime: info.ModTime()})
+	}
+
+	removed := 0
+	for _, path := range prunedRunLogs(files, settings.RunLogRetentionDays, settings.RunLogMaxFiles, time.Now()) {
+		if err := os.Remove(path); err != nil {
+			utils.Logger.Warnf("[%s]: Failed to remove run log %s: %v", instanceName, path, err)
+			continue
+		}
+		if err := model.ClearRunLogPath(path); err != nil {
+			utils.Logger.Warnf("[%s]: Failed to clear log path of removed run log %s: %v", instanceName, path, err)
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		utils.Logger.Infof("[%s]: Removed %d archived run logs", instanceName, removed)
+	}
+}
+
+// CleanupAllRunLogs applies the retention settings to the archived logs of every instance
+func CleanupAllRunLogs() {
+	names, err := model.GetAllIstNames()
+	if err != nil {
+		utils.Logger.Error("Failed to get instance names:", err)
+		return
+	}
+	for _, instanceName := range names {
+		CleanupRunLogs(instanceName)
+	}
+}
+
+// runLogFile is an archived run log found on disk
+type runLogFile struct {
+	path    string
+	modTime time.Time
+}
+
+// prunedRunLogs returns the paths of the logs that the retention settings remove
+// Logs older than retentionDays and all but the newest maxFiles logs are removed, 0 disables a limit
+func prunedRunLogs(files []runLogFile, retentionDays, maxFiles int, now time.Time) []string {
+	// Newest first, so the files beyond the limit are the oldest ones
+	files = slices.Clone(files)
+	slices.SortFunc(files, func(a, b runLogFile) int {
+		return b.modTime.Compare(a.modTime)
+	})
+
+	cutoff := now.AddDate(0, 0, -retentionDays)
+	pruned := make([]string, 0)
+	for i, file := range files {
+		expired := retentionDays > 0 && file.modTime.Before(cutoff)
+		overLimit := maxFiles > 0 && i >= maxFiles
+		if expired || overLimit {
+			pruned = append(pruned, file.path)
+		}
+	}
+	return pruned
+}
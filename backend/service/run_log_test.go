@@ -0,0 +1,90 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPrunedRunLogs(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	// Listed out of order, as read from the directory
+	files := []runLogFile{
+		{path: "b.log", modTime: daysAgo(10)},
+		{path: "a.log", modTime: daysAgo(1)},
+		{path: "d.log", modTime: daysAgo(40)},
+		{path: "c.log", modTime: daysAgo(20)},
+	}
+
+	tests := []struct {
+		name          string
+		retentionDays int
+		maxFiles      int
+		want          []string
+	}{
+		{name: "no limits", want: []string{}},
+		{name: "retention", retentionDays: 15, want: []string{"c.log", "d.log"}},
+		{name: "max files", maxFiles: 3, want: []string{"d.log"}},
+		{name: "both limits", retentionDays: 30, maxFiles: 1, want: []string{"b.log", "c.log", "d.log"}},
+		{name: "limits above the file count", retentionDays: 365, maxFiles: 10, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prunedRunLogs(files, tt.retentionDays, tt.maxFiles, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prunedRunLogs(%d days, %d files) = %v, want %v", tt.retentionDays, tt.maxFiles, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRunLogUniquePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// Runs started within the same second must not share a file
+	start := time.Date(2024, 6, 30, 12, 0, 0, 0, time.Local)
+	dir := filepath.Join(RunLogDir, "game_a")
+	wantPaths := []string{
+		filepath.Join(dir, "20240630-120000-daily.log"),
+		filepath.Join(dir, "20240630-120000-daily-1.log"),
+		filepath.Join(dir, "20240630-120000-daily-2.log"),
+	}
+	for _, want := range wantPaths {
+		runLog, err := NewRunLog("game:a", "daily", start)
+		if err != nil {
+			t.Fatalf("NewRunLog() error = %v", err)
+		}
+		runLog.Close()
+		if runLog.Path != want {
+			t.Errorf("NewRunLog().Path = %s, want %s", runLog.Path, want)
+		}
+	}
+}
+
+func TestRunLogDirStaysInside(t *testing.T) {
+	for _, name := range []string{".", "..", "...", "../..", `..\..`} {
+		dir := runLogDir(name)
+		if filepath.Dir(dir) != filepath.Clean(RunLogDir) {
+			t.Errorf("runLogDir(%q) = %s, want a directory directly under %s", name, dir, RunLogDir)
+		}
+	}
+
+	// Dots inside a name are kept
+	if got, want := runLogDir("game.a"), filepath.Join(RunLogDir, "game.a"); got != want {
+		t.Errorf("runLogDir(game.a) = %s, want %s", got, want)
+	}
+	if runLogDir(".") == runLogDir("..") {
+		t.Error(`"." and ".." share a log directory`)
+	}
+}
@@ -12,9 +12,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 	"unicode/utf8"
 
@@ -22,34 +22,64 @@ import (
 	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
-var ErrManualStop = errors.New("task manually stopped")
+var (
+	ErrManualStop    = errors.New("task manually stopped")
+	ErrTaskTimeout   = errors.New("task timed out")
+	ErrOutsideWindow = errors.New("outside execution window")
+	ErrTaskSkipped   = errors.New("task skipped")
+	ErrTaskRetry     = errors.New("task asked to be retried")
+)
 
 // Constants for scheduler configuration
 const (
-	MaxErrorLength      = 2000             // Maximum length of error message in notification
-	UpdateCheckInterval = 10 * time.Second // Interval to check for instance updates
+	MaxErrorLength          = 2000             // Maximum length of error message in notification
+	UpdateCheckInterval     = 10 * time.Second // Interval to check for instance updates
+	ExecWindowCheckInterval = 30 * time.Second // Interval to check whether the execution window has closed
 )
 
 type SchedulerService struct {
 	wsService    *WebSocketService
 	notifService *NotificationService
+	locks        *ResourceLocks
+	shutdown     shutdownState
 }
 
 // UpdateTaskQueue updates the task queue
 func (s *SchedulerService) UpdateTaskQueue(queues map[string]model.TaskQueue) {
 	scheduler := model.GetScheduler()
 	scheduler.UpdateQueue(queues)
+	for instanceName := range queues {
+		s.saveState(instanceName)
+	}
 }
 
 // UpdateInstanceStatus updates the instance status and broadcasts it
 func (s *SchedulerService) UpdateInstanceStatus(instanceName, status string) {
 	scheduler := model.GetScheduler()
 	scheduler.UpdateTaskManagerStatus(instanceName, status)
+	s.saveState(instanceName)
 	s.wsService.BroadcastState(instanceName, status)
 }
 
-// UpdateSchedulerState updates the scheduler state
-func (s *SchedulerService) UpdateSchedulerState(actionType, instanceName string) {
+// publishQueue saves the state of an instance and broadcasts its task queue
+func (s *SchedulerService) publishQueue(instanceName string) {
+	s.saveState(instanceName)
+	s.wsService.BroadcastQueue(instanceName)
+}
+
+// saveState stores the status and queue of an instance so that they are restored after a restart
+func (s *SchedulerService) saveState(instanceName string) {
+	tm := model.GetScheduler().GetTaskManager(instanceName)
+	if tm == nil {
+		return
+	}
+	if err := tm.SaveState(); err != nil {
+		utils.Logger.Errorf("[%s]: Failed to save scheduler state: %v", instanceName, err)
+	}
+}
+
+// UpdateSchedulerState starts, stops, pauses or resumes one instance or all of them
+func (s *SchedulerService) UpdateSchedulerState(actionType, instanceName string) error {
 	if actionType == "start" {
 		if instanceName == "" {
 			go s.StartAll()
@@ -64,6 +94,105 @@ func (s *SchedulerService) UpdateSchedulerState(actionType, instanceName string)
 		} else {
 			s.stopOne(instanceName, nil)
 		}
+	} else if actionType == "pause" || actionType == "resume" {
+		action := s.PauseOne
+		if actionType == "resume" {
+			action = s.ResumeOne
+		}
+		if instanceName != "" {
+			return action(instanceName)
+		}
+
+		// Without an instance name every running or paused instance is affected
+		names, err := model.GetAllIstNames()
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, name := range names {
+			if tm := model.GetScheduler().GetTaskManager(name); tm != nil && tm.IsBusy() {
+				if err := action(name); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// PauseOne suspends the running task of an instance and holds back its next tasks
+func (s *SchedulerService) PauseOne(instanceName string) error {
+	tm := model.GetScheduler().GetTaskManager(instanceName)
+	if tm == nil {
+		return fmt.Errorf("instance not found: %s", instanceName)
+	}
+	if tm.Status == model.StatusPaused {
+		return nil
+	}
+	if tm.Status != model.StatusRunning {
+		return fmt.Errorf("instance %s is not running", instanceName)
+	}
+
+	if err := tm.Suspend(); err != nil {
+		return fmt.Errorf("failed to suspend %s: %w", instanceName, err)
+	}
+	utils.Logger.Infof("[%s]: Paused", instanceName)
+	s.wsService.BroadcastLog(instanceName, "[DaCapo] Paused")
+	s.UpdateInstanceStatus(instanceName, model.StatusPaused)
+	return nil
+}
+
+// ResumeOne continues an instance paused by PauseOne
+func (s *SchedulerService) ResumeOne(instanceName string) error {
+	tm := model.GetScheduler().GetTaskManager(instanceName)
+	if tm == nil {
+		return fmt.Errorf("instance not found: %s", instanceName)
+	}
+	if tm.Status == model.StatusRunning {
+		return nil
+	}
+	if tm.Status != model.StatusPaused {
+		return fmt.Errorf("instance %s is not paused", instanceName)
+	}
+
+	if err := tm.Resume(); err != nil {
+		return fmt.Errorf("failed to resume %s: %w", instanceName, err)
+	}
+	utils.Logger.Infof("[%s]: Resumed", instanceName)
+	s.wsService.BroadcastLog(instanceName, "[DaCapo] Resumed")
+	s.UpdateInstanceStatus(instanceName, model.StatusRunning)
+	return nil
+}
+
+// waitWhilePaused blocks while the instance is paused or held for closing the app, and returns false
+// if it was stopped meanwhile or since the last command
+func (s *SchedulerService) waitWhilePaused(tm *model.TaskManager) bool {
+	for (tm.Paused.Load() || s.holdForShutdown(tm.InstanceName)) && !tm.ManualStop.Load() {
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	if tm.ManualStop.Swap(false) {
+		// Stopped between commands, there is no command that consumes the stop request
+		tm.Paused.Store(false)
+		return false
+	}
+	return true
+}
+
+// ReportInterrupted logs the instances whose run was cut short when the app last stopped
+// Instances that adopted the process of their interrupted task are not reported
+func (s *SchedulerService) ReportInterrupted() {
+	names, err := model.GetAllIstNames()
+	if err != nil {
+		utils.Logger.Error("Failed to get instance names:", err)
+		return
+	}
+	for _, instanceName := range names {
+		tm := model.GetScheduler().GetTaskManager(instanceName)
+		if tm != nil && tm.LastOutcome == model.OutcomeInterrupted {
+			s.wsService.BroadcastLog(instanceName, "[DaCapo] "+tm.LastError)
+		}
 	}
 }
 
@@ -72,12 +201,6 @@ func (s *SchedulerService) GetTaskQueue(instanceName string) {
 	s.wsService.BroadcastQueue(instanceName)
 }
 
-// SetSchedulerCron sets the scheduler cron expression
-func (s *SchedulerService) SetSchedulerCron(cronExpr string) {
-	scheduler := model.GetScheduler()
-	scheduler.CronExpr = cronExpr
-}
-
 // stopOne stops the instance-level task manager
 func (s *SchedulerService) stopOne(instanceName string, err error) {
 	scheduler := model.GetScheduler()
@@ -88,6 +211,9 @@ func (s *SchedulerService) stopOne(instanceName string, err error) {
 		scheduler.CancelTask(instanceName)
 		utils.Logger.Infof("[%s]: stopped manually", instanceName)
 		if tm != nil {
+			if tm.IsBusy() {
+				tm.LastOutcome = model.OutcomeStopped
+			}
 			tm.LastError = ""
 		}
 	} else {
@@ -95,6 +221,7 @@ func (s *SchedulerService) stopOne(instanceName string, err error) {
 		// Store the error message in TaskManager
 		if tm != nil {
 			tm.LastError = err.Error()
+			tm.LastOutcome = model.OutcomeFailed
 		}
 	}
 
@@ -105,7 +232,7 @@ func (s *SchedulerService) stopOne(instanceName string, err error) {
 
 	s.UpdateInstanceStatus(instanceName, status)
 	scheduler.TaskManagers[instanceName].RemoveRun()
-	s.wsService.BroadcastQueue(instanceName)
+	s.publishQueue(instanceName)
 	if err != nil {
 		s.wsService.BroadcastLog(instanceName, err.Error())
 	}
@@ -124,8 +251,15 @@ func (s *SchedulerService) getVenvPython(envName string) string {
 	return filepath.Join(venvPath, "bin", "python")
 }
 
-// runCommand executes a command and processes the output
-func (s *SchedulerService) RunCommand(tm *model.TaskManager, command string, workDir string) error {
+// RunOptions holds optional settings for RunCommand
+type RunOptions struct {
+	RunLog  *RunLog       // Archive the combined output if provided
+	Timeout time.Duration // Terminate the command after this duration, 0 means no limit
+	Task    string        // Task the command runs for, its process is recorded to find it again after a crash
+}
+
+// RunCommand executes a command and processes the output
+func (s *SchedulerService) RunCommand(tm *model.TaskManager, command string, workDir string, opts RunOptions) error {
 	// Create command
 	args, err := shellwords.Parse(command)
 	if len(args) == 0 {
@@ -135,11 +269,8 @@ func (s *SchedulerService) RunCommand(tm *model.TaskManager, command string, wor
 		return fmt.Errorf("failed to parse command: %w", err)
 	}
 	cmd := exec.Command(args[0], args[1:]...)
-	if runtime.GOOS == "windows" {
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			CreationFlags: 0x08000000,
-		}
-	}
+	// Run in a separate process group so that stopping also reaches child processes
+	utils.SetProcessGroup(cmd)
 
 	// Set environment variables to force color output
 	cmd.Env = append(os.Environ(),
@@ -171,7 +302,33 @@ func (s *SchedulerService) RunCommand(tm *model.TaskManager, command string, wor
 		return fmt.Errorf("failed to start command: %w", err)
 	}
 
-	tm.Cmd = cmd
+	tm.Cmd.Store(cmd)
+	tm.TimedOut.Store(false)
+	// A stop requested before this command is handled by the caller between commands
+	tm.ManualStop.Store(false)
+	if opts.Task != "" {
+		record := recordProcess(tm.InstanceName, opts.Task, cmd.Process.Pid)
+		defer forgetProcess(record)
+	}
+
+	// Terminate the command if it exceeds the timeout
+	if opts.Timeout > 0 {
+		var watchdog *time.Timer
+		accounted := tm.PausedDuration()
+		watchdog = time.AfterFunc(opts.Timeout, func() {
+			// Time spent paused does not count against the timeout
+			if paused := tm.PausedDuration(); tm.Paused.Load() || paused > accounted {
+				extra := paused - accounted
+				accounted = paused
+				watchdog.Reset(max(extra, time.Second))
+				return
+			}
+			utils.Logger.Warnf("[%s]: Command exceeded timeout of %v, terminating", tm.InstanceName, opts.Timeout)
+			tm.TimedOut.Store(true)
+			tm.Kill()
+		})
+		defer watchdog.Stop()
+	}
 
 	// Create wait group to ensure both goroutines complete
 	var wg sync.WaitGroup
@@ -180,39 +337,64 @@ func (s *SchedulerService) RunCommand(tm *model.TaskManager, command string, wor
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		s.processOutput(stdoutPipe, tm.InstanceName, false, nil)
+		s.processOutput(stdoutPipe, tm.InstanceName, false, nil, opts.RunLog)
 	}()
 	go func() {
 		defer wg.Done()
-		s.processOutput(stderrPipe, tm.InstanceName, true, &stderrBuf)
+		s.processOutput(stderrPipe, tm.InstanceName, true, &stderrBuf, opts.RunLog)
 	}()
 
 	// Wait for command to complete
 	err = cmd.Wait()
+	tm.Cmd.Store(nil)
 	wg.Wait()
 
+	if tm.TimedOut.Swap(false) {
+		return fmt.Errorf("%w after %v", ErrTaskTimeout, opts.Timeout)
+	}
+	// A command that exited cleanly on SIGTERM succeeded, the stop is then handled before the next command
+	if err != nil && tm.ManualStop.Swap(false) {
+		return ErrManualStop
+	}
+
 	if err != nil {
-		if tm.ManualStop {
-			tm.ManualStop = false
-			return ErrManualStop
+		cmdErr := &CommandError{ExitCode: -1, Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			cmdErr.ExitCode = exitErr.ExitCode()
 		}
 
-		// Build detailed error message with stderr content
+		// Limit error message length to avoid excessive size
 		stderrContent := stderrBuf.String()
-		if stderrContent != "" {
-			// Limit error message length to avoid excessive size
-			if len(stderrContent) > MaxErrorLength {
-				// Keep the last part which usually contains the actual error
-				stderrContent = "...\n" + stderrContent[len(stderrContent)-MaxErrorLength:]
-			}
-			return fmt.Errorf("command failed with exit code %v:\n%s", err, stderrContent)
+		if len(stderrContent) > MaxErrorLength {
+			// Keep the last part which usually contains the actual error
+			stderrContent = "...\n" + stderrContent[len(stderrContent)-MaxErrorLength:]
 		}
-		return fmt.Errorf("command failed: %w", err)
+		cmdErr.Stderr = stderrContent
+		return cmdErr
 	}
 
 	return nil
 }
 
+// CommandError describes a command that ran but exited unsuccessfully
+type CommandError struct {
+	ExitCode int    // Process exit code, -1 if unknown
+	Stderr   string // Tail of the stderr output
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	if e.Stderr != "" {
+		return fmt.Sprintf("command failed with exit code %v:\n%s", e.Err, e.Stderr)
+	}
+	return fmt.Sprintf("command failed: %v", e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
 // detectAndConvert detects encoding and converts to UTF-8
 func (s *SchedulerService) detectAndConvert(data []byte) string {
 	if utf8.Valid(data) {
@@ -229,7 +411,7 @@ func (s *SchedulerService) detectAndConvert(data []byte) string {
 
 // processOutput handles reading from a pipe and broadcasting/logging the output
 // If buf is provided, it will also capture the output
-func (s *SchedulerService) processOutput(pipe io.ReadCloser, instanceName string, isError bool, buf *bytes.Buffer) {
+func (s *SchedulerService) processOutput(pipe io.ReadCloser, instanceName string, isError bool, buf *bytes.Buffer, runLog *RunLog) {
 	defer pipe.Close()
 
 	reader := bufio.NewReader(pipe)
@@ -246,6 +428,7 @@ func (s *SchedulerService) processOutput(pipe io.ReadCloser, instanceName string
 		line = bytes.TrimRight(line, "\r\n")
 		if len(line) == 0 {
 			s.wsService.BroadcastLog(instanceName, "")
+			runLog.WriteLine("")
 			if buf != nil {
 				buf.WriteString("\n")
 			}
@@ -255,6 +438,7 @@ func (s *SchedulerService) processOutput(pipe io.ReadCloser, instanceName string
 		// Detect encoding and convert
 		text := s.detectAndConvert(line)
 		s.wsService.BroadcastLog(instanceName, text)
+		runLog.WriteLine(text)
 
 		// Capture to buffer if provided
 		if buf != nil {
@@ -298,13 +482,38 @@ func (s *SchedulerService) validateTaskManager(instanceName string) (tm *model.T
 	return tm, nil
 }
 
-// StartOne runs tasks for a single instance (public wrapper)
+// StartOne runs tasks for a single instance once its resources are free (public wrapper)
 func (s *SchedulerService) StartOne(instanceName string) {
-	s.startOne(instanceName)
+	// A stop requested while the instance was idle does not apply to this run
+	if tm := model.GetScheduler().GetTaskManager(instanceName); tm != nil {
+		tm.ManualStop.Store(false)
+	}
+
+	resources := s.instanceResources(instanceName)
+	if !s.locks.TryAcquire(resources) {
+		utils.Logger.Infof("[%s]: Waiting for resources to be released", instanceName)
+		s.wsService.BroadcastLog(instanceName, "[DaCapo] Waiting for resources to be released")
+
+		scheduler := model.GetScheduler()
+		stopped := func() bool {
+			tm := scheduler.GetTaskManager(instanceName)
+			return tm == nil || tm.ManualStop.Load()
+		}
+		if !s.locks.Acquire(resources, stopped) {
+			if tm := scheduler.GetTaskManager(instanceName); tm != nil {
+				tm.ManualStop.Store(false)
+			}
+			utils.Logger.Infof("[%s]: Stopped while waiting for resources", instanceName)
+			return
+		}
+	}
+	defer s.locks.Release(resources)
+
+	s.startOne(instanceName, resources)
 }
 
-// startOne runs tasks for a single instance and returns result
-func (s *SchedulerService) startOne(instanceName string) model.InstanceResult {
+// startOne runs tasks for a single instance that holds the resources held and returns result
+func (s *SchedulerService) startOne(instanceName string, held []model.Resource) model.InstanceResult {
 	tm, errResult := s.validateTaskManager(instanceName)
 	if errResult != nil {
 		return *errResult
@@ -321,6 +530,15 @@ func (s *SchedulerService) startOne(instanceName string) model.InstanceResult {
 		}
 	}
 
+	if s.isShuttingDown() {
+		utils.Logger.Warnf("[%s]: Cannot start - application is closing", instanceName)
+		return model.InstanceResult{
+			Name:    instanceName,
+			Success: false,
+			Error:   "Application is closing",
+		}
+	}
+
 	if tm.Status == model.StatusUpdating {
 		utils.Logger.Warnf("[%s]: Cannot start - instance is updating", instanceName)
 		return model.InstanceResult{
@@ -331,64 +549,579 @@ func (s *SchedulerService) startOne(instanceName string) model.InstanceResult {
 		}
 	}
 
-	// Clear previous error message when starting a new run
+	// Clear previous error message, stop requests and task states when starting a new run
 	tm.LastError = ""
+	tm.ManualStop.Store(false)
+	tm.WindowClosed.Store(false)
+	tm.ResetTaskStates()
+	CleanupRunLogs(instanceName)
 	s.UpdateInstanceStatus(instanceName, model.StatusRunning)
-	s.wsService.BroadcastQueue(instanceName)
-
-	for len(tm.Queue.Waiting) > 0 {
-		taskName := tm.SwitchRun()
-		s.wsService.BroadcastQueue(instanceName)
+	s.publishQueue(instanceName)
+
+	stopWatch := s.watchExecWindow(tm)
+	defer stopWatch()
+
+	retries := 0
+	outcomes := make(map[string]bool) // Tasks finished in this run, true on success
+	taskResults := make([]model.TaskResult, 0, len(tm.Queue.Waiting))
+	var failure *model.InstanceResult
+	var failureErr error
+	halted := false // A failed task stopped the instance, only cleanup tasks run
+	var istInfo model.InstanceInfo
+	for {
+		if !s.waitWhilePaused(tm) {
+			if tm.WindowClosed.Load() {
+				// The last task exited cleanly when the execution window closed
+				result := s.deferToWindow(tm, retries, ErrOutsideWindow)
+				result.Tasks = taskResults
+				return result
+			}
+			return model.InstanceResult{
+				Name:    instanceName,
+				Success: false,
+				Retries: retries,
+				Error:   "Manually stopped",
+				Tasks:   taskResults,
+			}
+		}
 
-		var istInfo model.InstanceInfo
+		istInfo = model.InstanceInfo{}
 		if err := istInfo.GetByName(instanceName); err != nil {
-			return failWithError(fmt.Errorf("failed to get instance info: %w", err), taskName)
+			return failWithError(fmt.Errorf("failed to get instance info: %w", err), "")
 		}
 
-		task := istInfo.GetTaskByName(taskName)
-		if task == nil {
-			return failWithError(fmt.Errorf("task not found: %s", taskName), taskName)
+		taskName, skipped := nextTask(&istInfo, tm.Queue.Waiting, outcomes, failure != nil, halted)
+		for _, name := range skipped {
+			tm.SkipTask(name)
+			outcomes[name] = false
+			taskResults = append(taskResults, model.TaskResult{
+				Name:    name,
+				Skipped: true,
+				Error:   "Skipped: dependency failed",
+			})
+			tm.SetTaskState(name, model.TaskState{
+				State:    model.TaskSkipped,
+				ExitCode: -1,
+				Reason:   "Dependency failed",
+			})
+			utils.Logger.Warnf("[%s]: task %s skipped because a dependency failed", instanceName, name)
+			s.wsService.BroadcastLog(instanceName, fmt.Sprintf("[DaCapo] Task %s skipped because a dependency failed", name))
 		}
-
-		cmd := task.Command
-		if strings.HasPrefix(task.Command, "py ") {
-			pythonExec := s.getVenvPython(istInfo.EnvName)
-			if pythonExec == "" {
-				return failWithError(fmt.Errorf("failed to find python executable in venv: %s", istInfo.EnvName), taskName)
+		if taskName == "" {
+			if len(skipped) > 0 {
+				s.publishQueue(instanceName)
+				continue
 			}
-			pythonExec, err := filepath.Abs(pythonExec)
-			if err != nil {
-				return failWithError(fmt.Errorf("failed to get absolute path: %w", err), taskName)
+			break
+		}
+
+		// Tasks are only started inside the execution window
+		if err := s.checkExecWindow(instanceName); err != nil {
+			tm.RemoveRun()
+			if failure != nil {
+				halted = true
+				break
 			}
-			cmd = strings.Replace(task.Command, "py ", "\""+pythonExec+"\" ", 1)
+			result := s.deferToWindow(tm, retries, err)
+			result.Tasks = taskResults
+			return result
 		}
 
-		utils.Logger.Infof("[%s]: Running task <%s>: %s", instanceName, taskName, cmd)
-		if err := s.RunCommand(tm, cmd, istInfo.WorkDir); err != nil {
+		tm.StartTask(taskName)
+		s.publishQueue(instanceName)
+
+		startTime := time.Now()
+		attempts, err := s.runQueuedTask(tm, &istInfo, taskName, held)
+		retries += max(attempts-1, 0)
+		tm.SetTaskState(taskName, taskState(err, time.Since(startTime)))
+		if errors.Is(err, ErrTaskSkipped) {
+			// Nothing to do is not a failure, so dependents still run
+			outcomes[taskName] = true
+			taskResults = append(taskResults, model.TaskResult{
+				Name:    taskName,
+				Skipped: true,
+				Retries: max(attempts-1, 0),
+				Error:   err.Error(),
+			})
+			utils.Logger.Infof("[%s]: task %s skipped: %v", instanceName, taskName, err)
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, ErrManualStop) && tm.WindowClosed.Load() {
+				if failure != nil {
+					// The window closed after a failure, which is reported instead
+					tm.WindowClosed.Store(false)
+					tm.ManualStop.Store(false)
+					tm.RequeueRun()
+					halted = true
+					break
+				}
+				// Run the interrupted task again in the next window
+				tm.RequeueRun()
+				result := s.deferToWindow(tm, retries, fmt.Errorf("%w, task %s stopped", ErrOutsideWindow, taskName))
+				result.Tasks = taskResults
+				return result
+			}
 			if errors.Is(err, ErrManualStop) {
 				return model.InstanceResult{
 					Name:     instanceName,
 					TaskName: taskName,
 					Success:  false,
+					Retries:  retries,
 					Error:    "Manually stopped",
+					Tasks:    taskResults,
 				}
 			}
-			return failWithError(err, taskName)
+
+			// Keep the first failure, the policy decides whether other tasks still run
+			outcomes[taskName] = false
+			tm.RemoveRun()
+			taskResults = append(taskResults, model.TaskResult{
+				Name:     taskName,
+				TimedOut: errors.Is(err, ErrTaskTimeout),
+				Retries:  max(attempts-1, 0),
+				Error:    err.Error(),
+			})
+			utils.Logger.Errorf("[%s]: task %s failed: %v", instanceName, taskName, err)
+			if istInfo.GetFailurePolicy(istInfo.GetTaskByName(taskName)) == model.FailureStopInstance {
+				halted = true
+			} else {
+				s.wsService.BroadcastLog(instanceName, fmt.Sprintf("[DaCapo] Task %s failed, continuing with the next task: %v", taskName, err))
+			}
+			if failure == nil {
+				failure = &model.InstanceResult{
+					Name:     instanceName,
+					TaskName: taskName,
+					Success:  false,
+					TimedOut: errors.Is(err, ErrTaskTimeout),
+					Error:    err.Error(),
+				}
+				failureErr = err
+			}
+			continue
 		}
 
+		outcomes[taskName] = true
+		taskResults = append(taskResults, model.TaskResult{
+			Name:    taskName,
+			Success: true,
+			Retries: max(attempts-1, 0),
+		})
 		utils.Logger.Infof("[%s]: task %s finished", instanceName, taskName)
 	}
+	tm.RemoveRun() // Clean up the last task
+
+	// Failed tasks whose policy continues with the next task do not fail the instance once the run completes
+	completed := !halted
+	if !halted {
+		// Tasks left behind are either cleanup tasks that are not needed or tasks waiting on each other
+		var blocked []string
+		for _, name := range slices.Clone(tm.Queue.Waiting) {
+			if task := istInfo.GetTaskByName(name); task != nil && !task.CanRun(failure != nil, false) {
+				tm.SkipTask(name)
+			} else {
+				blocked = append(blocked, name)
+			}
+		}
+		if len(blocked) > 0 {
+			completed = false
+		}
+		if len(blocked) > 0 && failure == nil {
+			failureErr = fmt.Errorf("tasks depend on each other: %s", strings.Join(blocked, ", "))
+			failure = &model.InstanceResult{
+				Name:    instanceName,
+				Success: false,
+				Error:   failureErr.Error(),
+			}
+		}
+	}
 
-	tm.SwitchRun() // Clean up the last task
+	if failure != nil {
+		failure.Retries = retries
+		failure.Tasks = taskResults
+		if completed {
+			// The result still reports the failed tasks
+			utils.Logger.Warnf("[%s]: run completed with failed tasks", instanceName)
+			tm.LastOutcome = model.OutcomePartial
+			s.UpdateInstanceStatus(instanceName, model.StatusPending)
+			s.publishQueue(instanceName)
+			return *failure
+		}
+		s.stopOne(instanceName, failureErr)
+		return *failure
+	}
+
+	tm.LastOutcome = model.OutcomeSuccess
 	s.UpdateInstanceStatus(instanceName, model.StatusPending)
-	s.wsService.BroadcastQueue(instanceName)
+	s.publishQueue(instanceName)
 
 	return model.InstanceResult{
 		Name:     instanceName,
 		TaskName: "", // Success - no specific failing task
 		Success:  true,
+		Retries:  retries,
 		Error:    "",
+		Tasks:    taskResults,
+	}
+}
+
+// nextTask picks the first waiting task whose dependencies have finished
+// Tasks whose dependency failed in this run are returned in skipped. Once a failed task has halted
+// the instance only cleanup tasks run, and their dependencies only decide the order
+func nextTask(istInfo *model.InstanceInfo, waiting []string, outcomes map[string]bool, failed, halted bool) (next string, skipped []string) {
+	willRun := func(name string) bool {
+		task := istInfo.GetTaskByName(name)
+		return (task == nil && !halted) || (task != nil && task.CanRun(failed, halted))
+	}
+
+	for _, name := range waiting {
+		if !willRun(name) {
+			continue
+		}
+		task := istInfo.GetTaskByName(name)
+		if task == nil {
+			return name, skipped // Reported as not found when it runs
+		}
+
+		deps := model.ParseDependencies(task.DependsOn)
+		if slices.ContainsFunc(deps, func(dep string) bool {
+			return slices.Contains(waiting, dep) && willRun(dep)
+		}) {
+			continue
+		}
+		if !task.IsCleanup() && slices.ContainsFunc(deps, func(dep string) bool {
+			success, done := outcomes[dep]
+			return done && !success
+		}) {
+			skipped = append(skipped, name)
+			continue
+		}
+		return name, skipped
+	}
+	return "", skipped
+}
+
+// taskState builds the queue state of a finished task from the error of its last attempt
+func taskState(err error, duration time.Duration) model.TaskState {
+	state := model.TaskState{
+		State:    model.TaskSucceeded,
+		Duration: duration.Milliseconds(),
+		ExitCode: exitCode(err),
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrManualStop):
+		state.State = model.TaskCancelled
+	case errors.Is(err, ErrTaskSkipped):
+		state.State = model.TaskSkipped
+		state.Reason = err.Error()
+	case errors.Is(err, ErrTaskTimeout):
+		state.State = model.TaskTimedOut
+		state.Reason = err.Error()
+	default:
+		state.State = model.TaskFailed
+		state.Reason = err.Error()
 	}
+	return state
+}
+
+// exitCode returns the exit code of a finished command, -1 if it did not exit on its own
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.ExitCode
+	}
+	return -1
+}
+
+// runQueuedTask runs a task of the instance with its resources held and returns the number of attempts
+// held are the resources the instance holds for the whole run
+func (s *SchedulerService) runQueuedTask(tm *model.TaskManager, istInfo *model.InstanceInfo, taskName string, held []model.Resource) (int, error) {
+	task := istInfo.GetTaskByName(taskName)
+	if task == nil {
+		return 0, fmt.Errorf("task not found: %s", taskName)
+	}
+
+	cmd := task.Command
+	if strings.HasPrefix(task.Command, "py ") {
+		pythonExec := s.getVenvPython(istInfo.EnvName)
+		if pythonExec == "" {
+			return 0, fmt.Errorf("failed to find python executable in venv: %s", istInfo.EnvName)
+		}
+		pythonExec, err := filepath.Abs(pythonExec)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		cmd = strings.Replace(task.Command, "py ", "\""+pythonExec+"\" ", 1)
+	}
+
+	release, err := s.acquireTaskResources(tm, istInfo, task, held)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	return s.runTaskWithRetry(tm, istInfo, task, cmd)
+}
+
+// checkExecWindow returns an error if tasks of the instance may not start now
+func (s *SchedulerService) checkExecWindow(instanceName string) error {
+	now := time.Now()
+
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Warn("Failed to load settings:", err)
+	}
+	if blackouts, err := model.ParseTimeWindows(settings.BlackoutPeriods); err != nil {
+		utils.Logger.Warnf("Ignoring invalid blackout periods: %v", err)
+	} else if model.InTimeWindows(blackouts, now) {
+		return fmt.Errorf("%w: in blackout period %s", ErrOutsideWindow, settings.BlackoutPeriods)
+	}
+
+	var istInfo model.InstanceInfo
+	if err := istInfo.GetByName(instanceName); err != nil {
+		return nil
+	}
+	windows, err := model.ParseTimeWindows(istInfo.ExecWindow)
+	if err != nil {
+		utils.Logger.Warnf("[%s]: Ignoring invalid execution window: %v", instanceName, err)
+		return nil
+	}
+	if len(windows) > 0 && !model.InTimeWindows(windows, now.In(istInfo.GetLocation())) {
+		return fmt.Errorf("%w %s", ErrOutsideWindow, istInfo.ExecWindow)
+	}
+	return nil
+}
+
+// watchExecWindow stops the running task when the execution window closes and the instance policy says so
+// The returned function ends the watch
+func (s *SchedulerService) watchExecWindow(tm *model.TaskManager) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ExecWindowCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if tm.Queue.Running == "" || tm.WindowClosed.Load() {
+				continue
+			}
+			err := s.checkExecWindow(tm.InstanceName)
+			if err == nil {
+				continue
+			}
+
+			var istInfo model.InstanceInfo
+			if err := istInfo.GetByName(tm.InstanceName); err != nil || istInfo.WindowPolicy != model.WindowPolicyStop {
+				continue
+			}
+			utils.Logger.Warnf("[%s]: %v, stopping task %s", tm.InstanceName, err, tm.Queue.Running)
+			s.wsService.BroadcastLog(tm.InstanceName, fmt.Sprintf("[DaCapo] %v, stopping task %s", err, tm.Queue.Running))
+			tm.WindowClosed.Store(true)
+			tm.Cancel()
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
+// deferToWindow ends a run because the execution window is closed, leaving the remaining tasks queued
+func (s *SchedulerService) deferToWindow(tm *model.TaskManager, retries int, err error) model.InstanceResult {
+	taskName := ""
+	if len(tm.Queue.Waiting) > 0 {
+		taskName = tm.Queue.Waiting[0]
+	}
+
+	// A stop requested by the window watch may arrive after the task already ended
+	tm.WindowClosed.Store(false)
+	tm.ManualStop.Store(false)
+	tm.LastOutcome = model.OutcomeStopped
+
+	utils.Logger.Warnf("[%s]: %v, remaining tasks stay queued", tm.InstanceName, err)
+	s.wsService.BroadcastLog(tm.InstanceName, fmt.Sprintf("[DaCapo] %v, remaining tasks stay queued", err))
+	s.UpdateInstanceStatus(tm.InstanceName, model.StatusPending)
+	s.publishQueue(tm.InstanceName)
+
+	return model.InstanceResult{
+		Name:     tm.InstanceName,
+		TaskName: taskName,
+		Success:  false,
+		Retries:  retries,
+		Error:    err.Error(),
+	}
+}
+
+// runTaskWithRetry runs a task and retries failed runs according to its retry policy
+// Returns the number of attempts made and the error of the last attempt
+func (s *SchedulerService) runTaskWithRetry(tm *model.TaskManager, istInfo *model.InstanceInfo, task *model.TaskInfo, cmd string) (int, error) {
+	maxAttempts := int(task.MaxRetries) + 1
+	if istInfo.GetFailurePolicy(task) == model.FailureContinue {
+		maxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		if !s.waitWhilePaused(tm) {
+			return attempt - 1, ErrManualStop
+		}
+		err := s.runTask(tm, istInfo, task, cmd, attempt)
+		if errors.Is(err, ErrTaskRetry) {
+			// A requested retry happens at least once, whatever the retry policy says
+			maxAttempts = max(maxAttempts, 2)
+		}
+		if err == nil || errors.Is(err, ErrManualStop) || errors.Is(err, ErrTaskSkipped) || attempt >= maxAttempts {
+			return attempt, err
+		}
+
+		delay := task.GetRetryDelay(attempt)
+		utils.Logger.Warnf("[%s]: Task %s failed (attempt %d/%d), retrying in %v: %v", istInfo.Name, task.Name, attempt, maxAttempts, delay, err)
+		s.wsService.BroadcastLog(istInfo.Name, fmt.Sprintf("[DaCapo] Task %s failed (attempt %d/%d), retrying in %v", task.Name, attempt, maxAttempts, delay))
+		if !s.waitRetry(tm, delay) {
+			return attempt, ErrManualStop
+		}
+	}
+}
+
+// waitRetry waits before a retry and returns false if the instance was stopped meanwhile
+func (s *SchedulerService) waitRetry(tm *model.TaskManager, delay time.Duration) bool {
+	deadline := time.Now().Add(delay)
+	for time.Now().Before(deadline) {
+		if tm.ManualStop.Load() {
+			break
+		}
+		time.Sleep(min(time.Second, time.Until(deadline)))
+	}
+
+	if tm.ManualStop.Swap(false) {
+		return false
+	}
+	return true
+}
+
+// runTask runs a single attempt of a task, archiving its output and recording it in the run history
+func (s *SchedulerService) runTask(tm *model.TaskManager, istInfo *model.InstanceInfo, task *model.TaskInfo, cmd string, attempt int) error {
+	utils.Logger.Infof("[%s]: Running task <%s> (attempt %d): %s", istInfo.Name, task.Name, attempt, cmd)
+	runLog, err := NewRunLog(istInfo.Name, task.Name, time.Now())
+	if err != nil {
+		utils.Logger.Warnf("[%s]: Failed to create run log: %v", istInfo.Name, err)
+	}
+	var logPath string
+	if runLog != nil {
+		logPath = runLog.Path
+	}
+
+	var history model.RunHistory
+	if err := history.Create(istInfo.Name, task.Name, attempt, cmd, logPath); err != nil {
+		utils.Logger.Warnf("[%s]: Failed to create run history: %v", istInfo.Name, err)
+	}
+
+	err = s.RunCommand(tm, cmd, istInfo.WorkDir, RunOptions{
+		RunLog:  runLog,
+		Timeout: istInfo.GetTaskTimeout(task),
+		Task:    task.Name,
+	})
+	runLog.Close()
+	err = applyExitCodes(task, err)
+	s.finishHistory(&history, err)
+	return err
+}
+
+// applyExitCodes turns the result of a command into the outcome the task declares for its exit code
+func applyExitCodes(task *model.TaskInfo, err error) error {
+	code := exitCode(err)
+	if code < 0 {
+		return err // Stopped, timed out or not started
+	}
+
+	switch task.GetExitOutcome(code) {
+	case model.ExitSuccess:
+		return nil
+	case model.ExitFailure:
+		if err == nil {
+			return &CommandError{ExitCode: code, Err: fmt.Errorf("exit status %d declared as failure", code)}
+		}
+	case model.ExitSkipped:
+		if err == nil {
+			return fmt.Errorf("%w, nothing to do (exit code %d)", ErrTaskSkipped, code)
+		}
+		return fmt.Errorf("%w, nothing to do: %w", ErrTaskSkipped, err)
+	case model.ExitRetry:
+		if err == nil {
+			return fmt.Errorf("%w (exit code %d)", ErrTaskRetry, code)
+		}
+		return fmt.Errorf("%w: %w", ErrTaskRetry, err)
+	}
+	return err
+}
+
+// finishHistory stores the outcome of a task run in its history record
+func (s *SchedulerService) finishHistory(history *model.RunHistory, err error) {
+	if history.ID == 0 {
+		return
+	}
+
+	outcome := model.OutcomeSuccess
+	stderrTail := ""
+	if err != nil {
+		outcome = model.OutcomeFailed
+		stderrTail = err.Error()
+
+		var cmdErr *CommandError
+		if errors.Is(err, ErrManualStop) {
+			outcome = model.OutcomeStopped
+			stderrTail = ""
+		} else if errors.Is(err, ErrTaskSkipped) {
+			outcome = model.OutcomeSkipped
+			stderrTail = ""
+		} else if errors.Is(err, ErrTaskTimeout) {
+			outcome = model.OutcomeTimeout
+		} else if errors.As(err, &cmdErr) {
+			stderrTail = cmdErr.Stderr
+		}
+	}
+
+	if err := history.Finish(outcome, exitCode(err), stderrTail); err != nil {
+		utils.Logger.Warnf("[%s]: Failed to save run history: %v", history.InstanceName, err)
+	}
+}
+
+// StartAllScheduled starts all instances for a scheduled run, rebuilding their queues first
+func (s *SchedulerService) StartAllScheduled() {
+	names, err := model.GetAllIstNames()
+	if err != nil {
+		utils.Logger.Error("Failed to get instance names:", err)
+	}
+	for _, name := range names {
+		s.rebuildQueue(name)
+	}
+	s.StartAll()
+}
+
+// StartOneScheduled starts an instance for a scheduled run, rebuilding its queue first
+func (s *SchedulerService) StartOneScheduled(instanceName string) {
+	s.rebuildQueue(instanceName)
+	s.StartOne(instanceName)
+}
+
+// rebuildQueue refills the queue of an instance according to the queue rebuild setting
+func (s *SchedulerService) rebuildQueue(instanceName string) {
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Warn("Failed to load settings:", err)
+	}
+	if err := model.GetScheduler().RebuildQueue(instanceName, settings.QueueRebuild); err != nil {
+		utils.Logger.Errorf("[%s]: Failed to rebuild task queue: %v", instanceName, err)
+		return
+	}
+	s.publishQueue(instanceName)
 }
 
 // StartAll starts tasks for all instances
@@ -409,9 +1142,8 @@ func (s *SchedulerService) StartAll() {
 		return
 	}
 
-	// Divide instances into foreground and background categories
-	var foregroundTasks []string
-	var backgroundTasks []string
+	// Collect runnable instances in execution order
+	var instanceNames []string
 	for _, ist := range instances {
 		if !ist.Ready {
 			continue
@@ -421,34 +1153,18 @@ func (s *SchedulerService) StartAll() {
 			utils.Logger.Infof("Skip failed/missing instance: %s", ist.Name)
 			continue
 		}
-
-		if ist.Background {
-			backgroundTasks = append(backgroundTasks, ist.Name)
-		} else {
-			foregroundTasks = append(foregroundTasks, ist.Name)
-		}
+		instanceNames = append(instanceNames, ist.Name)
 	}
-	utils.Logger.Infof("Background tasks (%d): %v", len(backgroundTasks), backgroundTasks)
-	utils.Logger.Infof("Foreground tasks (%d): %v", len(foregroundTasks), foregroundTasks)
+	utils.Logger.Infof("Instances (%d): %v", len(instanceNames), instanceNames)
 
 	// Initialize result tracking
-	resultChan := make(chan model.InstanceResult, len(backgroundTasks)+len(foregroundTasks))
+	resultChan := make(chan model.InstanceResult, len(instanceNames))
 
-	// Handle background tasks
-	if len(backgroundTasks) > 0 {
+	if len(instanceNames) > 0 {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			s.runBackgroundTasks(backgroundTasks, resultChan)
-		}()
-	}
-
-	// Handle foreground tasks
-	if len(foregroundTasks) > 0 {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			s.runForegroundTasks(foregroundTasks, resultChan)
+			s.runInstances(instanceNames, resultChan)
 		}()
 	}
 
@@ -478,122 +1194,258 @@ func (s *SchedulerService) StartAll() {
 	}()
 }
 
-// runWithCheck waits for instance updates to complete before execution and returns result
-func (s *SchedulerService) runWithCheck(istName string) model.InstanceResult {
+// runInstances starts instances in order as soon as their dependencies have finished and the resources
+// they declare are free. An instance waiting for a resource keeps later instances from taking that
+// resource, so the order is kept
+func (s *SchedulerService) runInstances(instanceNames []string, resultChan chan<- model.InstanceResult) {
 	scheduler := model.GetScheduler()
-	for {
-		if !scheduler.IsRunning {
-			return model.InstanceResult{
-				Name:     istName,
-				TaskName: "",
-				Success:  false,
-				Error:    "Scheduler stopped",
-			}
-		}
-
-		tm, errResult := s.validateTaskManager(istName)
-		if errResult != nil {
-			utils.Logger.Warnf("Skip instance %s: %s", istName, errResult.Error)
-			return *errResult
-		}
-
-		if tm.Status == model.StatusUpdating {
-			time.Sleep(UpdateCheckInterval)
-			continue
-		}
-
-		return s.startOne(istName)
-	}
-}
-
-// runBackgroundTasks executes background tasks concurrently with limit
-func (s *SchedulerService) runBackgroundTasks(tasks []string, resultChan chan<- model.InstanceResult) {
-	utils.Logger.Info("Starting background tasks")
 	var wg sync.WaitGroup
 
-	// Load max concurrent setting
-	settings, err := model.LoadSettings()
+	graph, err := model.GetDependencyGraph()
 	if err != nil {
-		utils.Logger.Warn("Failed to load settings, using default concurrency")
-		settings = &model.AppSettings{MaxBgConcurrent: 0}
+		utils.Logger.Error("Failed to get instance dependencies:", err)
+		graph = make(map[string][]string)
 	}
-	maxConcurrent := settings.MaxBgConcurrent
-	if maxConcurrent < 0 {
-		maxConcurrent = 0 // Fallback to no limit
+	pending := s.rejectDependencyCycles(instanceNames, graph, resultChan)
+	run := slices.Clone(pending)
+
+	// Outcome of every instance that has finished in this run, true on success
+	var finishedMu sync.Mutex
+	finished := make(map[string]bool)
+	finishedCh := make(chan struct{}, len(pending))
+	finish := func(result model.InstanceResult) {
+		finishedMu.Lock()
+		finished[result.Name] = result.Success
+		finishedMu.Unlock()
+		resultChan <- result
+		finishedCh <- struct{}{}
 	}
 
-	if maxConcurrent > 0 {
-		utils.Logger.Infof("Max concurrent background tasks: %d", maxConcurrent)
-	} else {
-		utils.Logger.Info("Max concurrent background tasks: unlimited")
-	}
+	for len(pending) > 0 && scheduler.IsRunning {
+		changed := s.locks.Changed()
+		remaining := make([]string, 0, len(pending))
+		waitedFor := make(map[string]bool) // Resources wanted by earlier waiting instances
+
+		for _, istName := range pending {
+			finishedMu.Lock()
+			deps, ready, failedDep := s.checkDependencies(istName, graph, run, finished)
+			finishedMu.Unlock()
+			if !ready {
+				remaining = append(remaining, istName)
+				continue
+			}
+			if failedDep != "" && s.dependencyPolicy(istName) == model.DependencySkip {
+				utils.Logger.Warnf("[%s]: Skipped because dependency %s failed", istName, failedDep)
+				s.wsService.BroadcastLog(istName, fmt.Sprintf("[DaCapo] Skipped because dependency %s failed", failedDep))
+				finish(model.InstanceResult{
+					Name:      istName,
+					Success:   false,
+					Error:     fmt.Sprintf("Skipped: dependency %s failed", failedDep),
+					DependsOn: deps,
+					Skipped:   true,
+				})
+				continue
+			}
 
-	var semaphore chan struct{}
-	if maxConcurrent > 0 {
-		semaphore = make(chan struct{}, maxConcurrent)
-	}
+			tm, errResult := s.validateTaskManager(istName)
+			if errResult != nil {
+				utils.Logger.Warnf("Skip instance %s: %s", istName, errResult.Error)
+				errResult.DependsOn = deps
+				finish(*errResult)
+				continue
+			}
 
-	for _, istName := range tasks {
-		if !model.GetScheduler().IsRunning {
-			return
-		}
+			// Updating instances are retried later without holding back the others
+			if tm.Status == model.StatusUpdating {
+				remaining = append(remaining, istName)
+				continue
+			}
 
-		wg.Add(1)
-		go func(name string) {
-			defer wg.Done()
+			resources := s.instanceResources(istName)
+			blocked := slices.ContainsFunc(resources, func(r model.Resource) bool {
+				return waitedFor[r.Name]
+			})
+			if blocked || !s.locks.TryAcquire(resources) {
+				remaining = append(remaining, istName)
+				for _, resource := range resources {
+					waitedFor[resource.Name] = true
+				}
+				continue
+			}
 
-			if semaphore != nil {
-				semaphore <- struct{}{}
-				defer func() { <-semaphore }()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result := s.startOne(istName, resources)
+				s.locks.Release(resources)
+				result.DependsOn = deps
+				finish(result)
+			}()
+		}
+
+		pending = remaining
+		if len(pending) > 0 {
+			select {
+			case <-changed:
+			case <-finishedCh:
+			case <-time.After(UpdateCheckInterval):
 			}
+		}
+	}
 
-			result := s.runWithCheck(name)
-			resultChan <- result
-		}(istName)
+	// Instances still waiting when the scheduler was stopped never started
+	for _, istName := range pending {
+		finish(model.InstanceResult{
+			Name:    istName,
+			Success: false,
+			Error:   "Scheduler stopped",
+		})
 	}
 
 	wg.Wait()
-	utils.Logger.Info("All background tasks completed")
+	utils.Logger.Info("All instances completed")
 }
 
-// runForegroundTasks executes foreground tasks sequentially
-func (s *SchedulerService) runForegroundTasks(tasks []string, resultChan chan<- model.InstanceResult) {
-	utils.Logger.Info("Starting foreground tasks")
-	scheduler := model.GetScheduler()
-
-	for len(tasks) > 0 {
-		allUpdating := true
-		remainingTasks := make([]string, 0, len(tasks))
+// rejectDependencyCycles reports the instances that depend on each other in a cycle and returns the rest
+func (s *SchedulerService) rejectDependencyCycles(instanceNames []string, graph map[string][]string, resultChan chan<- model.InstanceResult) []string {
+	remaining := slices.Clone(instanceNames)
+	for {
+		cycle := model.FindDependencyCycle(graph)
+		if cycle == nil {
+			return remaining
+		}
 
-		for _, istName := range tasks {
-			if !scheduler.IsRunning {
-				return
+		errMsg := fmt.Sprintf("Dependency cycle: %s", strings.Join(cycle, " → "))
+		utils.Logger.Error(errMsg)
+		for _, name := range cycle[:len(cycle)-1] {
+			deps := graph[name]
+			delete(graph, name)
+			if slices.Contains(remaining, name) {
+				remaining = slices.DeleteFunc(remaining, func(n string) bool { return n == name })
+				resultChan <- model.InstanceResult{
+					Name:      name,
+					Success:   false,
+					Error:     errMsg,
+					DependsOn: deps,
+				}
 			}
+		}
+	}
+}
 
-			tm, errResult := s.validateTaskManager(istName)
-			if errResult != nil {
-				utils.Logger.Warnf("Skip instance %s: %s", istName, errResult.Error)
-				resultChan <- *errResult
-				continue
+// checkDependencies reports whether all dependencies of an instance have finished in this run
+// run holds every instance of the run, whether it is waiting, running or finished. failedDep is the
+// first dependency that did not succeed. Dependencies outside the run only count when they are in failed state
+func (s *SchedulerService) checkDependencies(istName string, graph map[string][]string, run []string, finished map[string]bool) (deps []string, ready bool, failedDep string) {
+	deps = graph[istName]
+	for _, dep := range deps {
+		success, done := finished[dep]
+		if !done && slices.Contains(run, dep) {
+			// Still waiting or running
+			return deps, false, ""
+		}
+		if !done {
+			// Not part of this run, e.g. not ready or skipped before the run started
+			if tm := model.GetScheduler().GetTaskManager(dep); tm != nil && tm.Status == model.StatusFailed && failedDep == "" {
+				failedDep = dep
 			}
+			continue
+		}
+		if !success && failedDep == "" {
+			failedDep = dep
+		}
+	}
+	return deps, true, failedDep
+}
 
-			if tm.Status == model.StatusUpdating {
-				remainingTasks = append(remainingTasks, istName)
-			} else {
-				allUpdating = false
-				result := s.startOne(istName)
-				resultChan <- result
+// dependencyPolicy returns what to do with an instance whose dependency failed
+func (s *SchedulerService) dependencyPolicy(istName string) string {
+	var istInfo model.InstanceInfo
+	if err := istInfo.GetByName(istName); err != nil {
+		return model.DependencySkip
+	}
+	return istInfo.DependencyPolicy
+}
+
+// instanceResources returns the resources an instance holds during its whole run
+// Instances without the background flag also hold the foreground resource, background ones share the
+// background resource when the concurrency limit is set
+func (s *SchedulerService) instanceResources(instanceName string) []model.Resource {
+	var istInfo model.InstanceInfo
+	if err := istInfo.GetByName(instanceName); err != nil {
+		utils.Logger.Errorf("[%s]: Failed to get instance info: %v", instanceName, err)
+		return nil
+	}
+
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Warn("Failed to load settings, using default concurrency")
+	}
+	resources, err := model.ParseResources(istInfo.Resources)
+	if err != nil {
+		utils.Logger.Warnf("[%s]: Ignoring invalid resources: %v", instanceName, err)
+		resources = nil
+	}
+	resources = withCapacity(resources, settings)
+
+	implicit := model.Resource{Name: model.ResourceForeground, Capacity: 1}
+	if istInfo.Background {
+		if settings.MaxBgConcurrent <= 0 {
+			return resources
+		}
+		implicit = model.Resource{Name: model.ResourceBackground, Capacity: settings.MaxBgConcurrent}
+	}
+	if !slices.ContainsFunc(resources, func(r model.Resource) bool { return r.Name == implicit.Name }) {
+		resources = append(resources, implicit)
+	}
+	return resources
+}
+
+// withCapacity overrides the declared capacity of resources listed in the resource capacity setting, so
+// that every holder of such a resource sees the same limit however it declared the resource
+func withCapacity(resources []model.Resource, settings *model.AppSettings) []model.Resource {
+	capacities, err := model.ParseResources(settings.ResourceCapacity)
+	if err != nil {
+		utils.Logger.Warnf("Ignoring invalid resource capacities: %v", err)
+	}
+	for i := range resources {
+		for _, c := range capacities {
+			if c.Name == resources[i].Name {
+				resources[i].Capacity = c.Capacity
 			}
 		}
+	}
+	return resources
+}
 
-		tasks = remainingTasks
-		if allUpdating && len(tasks) > 0 {
-			utils.Logger.Debug("All remaining foreground tasks are updating, waiting...")
-			time.Sleep(UpdateCheckInterval)
+// acquireTaskResources waits for the resources of a task that the instance does not hold already
+// The resources held by the instance stay taken while waiting. Returns ErrManualStop if the instance
+// is stopped while waiting
+func (s *SchedulerService) acquireTaskResources(tm *model.TaskManager, istInfo *model.InstanceInfo, task *model.TaskInfo, held []model.Resource) (func(), error) {
+	taskResources, err := model.ParseResources(task.Resources)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Warn("Failed to load settings, using default concurrency")
+	}
+	resources := slices.DeleteFunc(withCapacity(taskResources, settings), func(r model.Resource) bool {
+		return slices.ContainsFunc(held, func(h model.Resource) bool { return h.Name == r.Name })
+	})
+
+	if !s.locks.TryAcquire(resources) {
+		utils.Logger.Infof("[%s]: Task %s is waiting for resources %s", istInfo.Name, task.Name, task.Resources)
+		s.wsService.BroadcastLog(istInfo.Name, fmt.Sprintf("[DaCapo] Task %s is waiting for resources %s", task.Name, task.Resources))
+		if !s.locks.Acquire(resources, func() bool { return tm.ManualStop.Load() }) {
+			tm.ManualStop.Store(false)
+			return nil, ErrManualStop
 		}
 	}
 
-	utils.Logger.Info("All foreground tasks completed")
+	return func() {
+		s.locks.Release(resources)
+	}, nil
 }
 
 // StopAll stops the scheduler and all tasks
@@ -615,13 +1467,19 @@ func (s *SchedulerService) StopAll() {
 		utils.Logger.Error("Failed to get all instances:", err)
 		return
 	}
-	// Stop all running instances
+	// Stop all running instances in parallel, each may wait for its grace period
+	var wg sync.WaitGroup
 	for _, ist := range instances {
 		tm := scheduler.GetTaskManager(ist.Name)
-		if tm != nil && tm.Status == model.StatusRunning {
-			s.stopOne(ist.Name, nil)
+		if tm != nil && tm.IsBusy() {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				s.stopOne(name, nil)
+			}(ist.Name)
 		}
 	}
+	wg.Wait()
 
 	scheduler.TriggerCloseFunc()
 }
@@ -638,6 +1496,9 @@ func (s *SchedulerService) buildSchedulerResult(results []model.InstanceResult)
 	}
 
 	for _, result := range results {
+		if result.Skipped {
+			schedulerResult.SkippedCount++
+		}
 		if result.Success {
 			schedulerResult.SuccessCount++
 		} else {
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNextTask(t *testing.T) {
+	istInfo := &model.InstanceInfo{
+		Tasks: []model.TaskInfo{
+			{Name: "login"},
+			{Name: "daily", DependsOn: "login"},
+			{Name: "weekly", DependsOn: "login, daily"},
+			{Name: "report", RunIfFailed: true},
+			{Name: "logout", Always: true, DependsOn: "daily"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		waiting     []string
+		outcomes    map[string]bool
+		failed      bool
+		halted      bool
+		wantNext    string
+		wantSkipped []string
+	}{
+		{
+			name:     "first waiting task",
+			waiting:  []string{"login", "daily"},
+			wantNext: "login",
+		},
+		{
+			name:     "dependency waiting behind the task",
+			waiting:  []string{"weekly", "daily", "login"},
+			wantNext: "login",
+		},
+		{
+			name:     "dependencies succeeded",
+			waiting:  []string{"weekly"},
+			outcomes: map[string]bool{"login": true, "daily": true},
+			wantNext: "weekly",
+		},
+		{
+			// Tasks waiting for the skipped task are decided once it has left the queue
+			name:        "dependency failed",
+			waiting:     []string{"daily", "weekly", "logout"},
+			outcomes:    map[string]bool{"login": false},
+			failed:      true,
+			wantNext:    "",
+			wantSkipped: []string{"daily"},
+		},
+		{
+			name:        "cleanup task after a failed dependency",
+			waiting:     []string{"weekly", "logout"},
+			outcomes:    map[string]bool{"login": false, "daily": false},
+			failed:      true,
+			wantNext:    "logout",
+			wantSkipped: []string{"weekly"},
+		},
+		{
+			name:     "dependency not in this run",
+			waiting:  []string{"daily"},
+			wantNext: "daily",
+		},
+		{
+			name:     "failure cleanup without a failure",
+			waiting:  []string{"report"},
+			wantNext: "",
+		},
+		{
+			name:     "failure cleanup after a failure",
+			waiting:  []string{"report"},
+			outcomes: map[string]bool{"login": false},
+			failed:   true,
+			wantNext: "report",
+		},
+		{
+			name:     "halted runs only cleanup tasks",
+			waiting:  []string{"daily", "report", "logout"},
+			failed:   true,
+			halted:   true,
+			wantNext: "report",
+		},
+		{
+			name:     "dependency of a cleanup task that will not run",
+			waiting:  []string{"daily", "logout"},
+			failed:   true,
+			halted:   true,
+			wantNext: "logout",
+		},
+		{
+			name:     "unknown task",
+			waiting:  []string{"removed", "login"},
+			wantNext: "removed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcomes := tt.outcomes
+			if outcomes == nil {
+				outcomes = make(map[string]bool)
+			}
+			next, skipped := nextTask(istInfo, tt.waiting, outcomes, tt.failed, tt.halted)
+			if next != tt.wantNext || !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("nextTask(%v) = %q, %v, want %q, %v", tt.waiting, next, skipped, tt.wantNext, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestCheckDependencies(t *testing.T) {
+	s := &SchedulerService{}
+	graph := map[string][]string{"A": {}, "B": {"A"}}
+	run := []string{"A", "B"}
+
+	// A has started and left the pending list, but has not finished yet
+	if _, ready, _ := s.checkDependencies("B", graph, run, map[string]bool{}); ready {
+		t.Error("B is ready while its dependency A is still running")
+	}
+
+	_, ready, failedDep := s.checkDependencies("B", graph, run, map[string]bool{"A": true})
+	if !ready || failedDep != "" {
+		t.Errorf("after A succeeded: ready = %v, failedDep = %q, want true and none", ready, failedDep)
+	}
+
+	_, ready, failedDep = s.checkDependencies("B", graph, run, map[string]bool{"A": false})
+	if !ready || failedDep != "A" {
+		t.Errorf("after A failed: ready = %v, failedDep = %q, want true and A", ready, failedDep)
+	}
+}
+
+func TestWithCapacity(t *testing.T) {
+	settings := &model.AppSettings{ResourceCapacity: "gpu:3"}
+	declared, err := model.ParseResources("emulator:2, gpu:1, account")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := withCapacity(declared, settings)
+	want := []model.Resource{
+		{Name: "emulator", Capacity: 2}, // Declared capacity is kept
+		{Name: "gpu", Capacity: 3},      // The setting takes precedence
+		{Name: "account", Capacity: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("withCapacity() = %v, want %v", got, want)
+	}
+}
+
+func TestTaskState(t *testing.T) {
+	if state := taskState(nil, 1500*time.Millisecond); state.State != model.TaskSucceeded || state.Duration != 1500 || state.ExitCode != 0 || state.Reason != "" {
+		t.Errorf("taskState(nil) = %+v, want a clean success taking 1500ms", state)
+	}
+
+	failed := taskState(&CommandError{ExitCode: 2, Err: errors.New("exit status 2")}, time.Second)
+	if failed.State != model.TaskFailed || failed.ExitCode != 2 || failed.Reason == "" {
+		t.Errorf("taskState() of a failed command = %+v, want failed with exit code 2", failed)
+	}
+
+	// The errors of the runner are wrapped with details of the run
+	timedOut := taskState(fmt.Errorf("%w after %v", ErrTaskTimeout, time.Minute), time.Minute)
+	if timedOut.State != model.TaskTimedOut || timedOut.ExitCode != -1 {
+		t.Errorf("taskState() of a timeout = %+v, want timed out with exit code -1", timedOut)
+	}
+	skipped := taskState(fmt.Errorf("%w, nothing to do (exit code %d)", ErrTaskSkipped, 3), 0)
+	if skipped.State != model.TaskSkipped || skipped.Reason != "task skipped, nothing to do (exit code 3)" {
+		t.Errorf("taskState() of a skip = %+v, want skipped with the reason", skipped)
+	}
+	if cancelled := taskState(ErrManualStop, 0); cancelled.State != model.TaskCancelled || cancelled.Reason != "" {
+		t.Errorf("taskState() of a manual stop = %+v, want cancelled without a reason", cancelled)
+	}
+}
@@ -12,6 +12,7 @@ type ServiceManager struct {
 	instanceUpdaterService *InstanceUpdaterService
 	wsService              *WebSocketService
 	notificationService    *NotificationService
+	cronService            *CronService
 
 	once sync.Once
 }
@@ -38,7 +39,7 @@ func (sm *ServiceManager) init() {
 		}
 
 		// Create WebSocket service first (no dependencies)
-		sm.wsService = &WebSocketService{}
+		sm.wsService = NewWebSocketService()
 
 		// Create notification service (optional)
 		if err == nil && settings.ServerChanSendKey != "" {
@@ -49,8 +50,12 @@ func (sm *ServiceManager) init() {
 		sm.schedulerService = &SchedulerService{
 			wsService:    sm.wsService,
 			notifService: sm.notificationService,
+			locks:        NewResourceLocks(),
 		}
 
+		// Create cron service with dependencies
+		sm.cronService = NewCronService(sm.schedulerService)
+
 		// Create instance service (no dependencies)
 		sm.instanceService = &InstanceService{}
 
@@ -83,6 +88,10 @@ func (sm *ServiceManager) NotificationService() *NotificationService {
 	return sm.notificationService
 }
 
+func (sm *ServiceManager) CronService() *CronService {
+	return sm.cronService
+}
+
 // ReloadNotificationService reloads the notification service with new settings
 func (sm *ServiceManager) ReloadNotificationService() error {
 	settings, err := model.LoadSettings()
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"dacapo/backend/utils"
+	"sync"
+	"time"
+)
+
+// shutdownState tracks a close of the app that waits for running instances
+type shutdownState struct {
+	mu      sync.Mutex
+	pending bool
+	ready   bool // Waiting is over, the next close request goes through
+	policy  string
+	cancel  chan struct{}
+	held    map[string]bool // Instances that finished their task and wait for the app to close
+}
+
+// RequestShutdown decides whether the app may close now
+// If instances are running and the shutdown policy waits for them, it returns true to keep the app open
+// and calls quit once they are done. A second request while waiting closes the app right away
+func (s *SchedulerService) RequestShutdown(quit func()) bool {
+	s.shutdown.mu.Lock()
+	defer s.shutdown.mu.Unlock()
+
+	if s.shutdown.ready {
+		return false
+	}
+	if s.shutdown.pending {
+		utils.Logger.Info("Close requested again, not waiting for running instances")
+		s.shutdown.pending = false
+		s.shutdown.ready = true
+		close(s.shutdown.cancel)
+		return false
+	}
+
+	settings, err := model.LoadSettings()
+	if err != nil {
+		utils.Logger.Warn("Failed to load settings:", err)
+	}
+	if settings.ShutdownPolicy == model.ShutdownStop || len(s.busyInstances(settings.ShutdownPolicy)) == 0 {
+		s.shutdown.ready = true
+		return false
+	}
+
+	s.shutdown.pending = true
+	s.shutdown.policy = settings.ShutdownPolicy
+	s.shutdown.cancel = make(chan struct{})
+	s.shutdown.held = make(map[string]bool)
+	go s.waitForShutdown(settings.ShutdownPolicy, time.Duration(settings.ShutdownTimeout)*time.Second, s.shutdown.cancel, quit)
+	return true
+}
+
+// CancelShutdown keeps the app open after a close request that is still waiting for running instances
+func (s *SchedulerService) CancelShutdown() {
+	s.shutdown.mu.Lock()
+	defer s.shutdown.mu.Unlock()
+
+	if !s.shutdown.pending {
+		return
+	}
+	close(s.shutdown.cancel)
+	s.shutdown.pending = false
+	s.shutdown.held = nil
+	utils.Logger.Info("Close cancelled")
+}
+
+// isShuttingDown reports whether the app is closing, no run is started meanwhile
+func (s *SchedulerService) isShuttingDown() bool {
+	s.shutdown.mu.Lock()
+	defer s.shutdown.mu.Unlock()
+	return s.shutdown.pending || s.shutdown.ready
+}
+
+// holdForShutdown reports whether an instance has to wait before its next task because the app is
+// closing after the running tasks. The instance is no longer waited for once it holds
+func (s *SchedulerService) holdForShutdown(instanceName string) bool {
+	s.shutdown.mu.Lock()
+	defer s.shutdown.mu.Unlock()
+
+	hold := s.shutdown.ready || (s.shutdown.pending && s.shutdown.policy == model.ShutdownWaitTask)
+	if hold && s.shutdown.held != nil {
+		s.shutdown.held[instanceName] = true
+	}
+	return hold
+}
+
+// busyInstances returns the instances a close of the app has to wait for under a policy
+func (s *SchedulerService) busyInstances(policy string) []string {
+	names, err := model.GetAllIstNames()
+	if err != nil {
+		utils.Logger.Error("Failed to get instance names:", err)
+		return nil
+	}
+
+	busy := make([]string, 0, len(names))
+	for _, name := range names {
+		tm := model.GetScheduler().GetTaskManager(name)
+		if tm == nil || !tm.IsBusy() {
+			continue
+		}
+		if policy == model.ShutdownWaitTask && s.shutdown.held[name] {
+			continue
+		}
+		busy = append(busy, name)
+	}
+	return busy
+}
+
+// waitForShutdown counts down until the instances are done or the timeout has passed, then stops what
+// is still running and quits. A timeout of 0 waits without limit
+func (s *SchedulerService) waitForShutdown(policy string, timeout time.Duration, cancel chan struct{}, quit func()) {
+	deadline := time.Now().Add(timeout)
+	utils.Logger.Infof("Close requested, waiting for running instances (%s)", policy)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		s.shutdown.mu.Lock()
+		busy := s.busyInstances(policy)
+		s.shutdown.mu.Unlock()
+
+		remaining := -1
+		if timeout > 0 {
+			remaining = max(int(time.Until(deadline).Round(time.Second).Seconds()), 0)
+		}
+		if len(busy) == 0 || remaining == 0 {
+			break
+		}
+		s.broadcastShutdown("waiting", policy, remaining, busy)
+
+		select {
+		case <-cancel:
+			if s.isShuttingDown() {
+				// Closed by a second request, which stops the instances itself
+				s.broadcastShutdown("closing", policy, 0, nil)
+			} else {
+				s.broadcastShutdown("cancelled", policy, 0, nil)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+
+	s.shutdown.mu.Lock()
+	if !s.shutdown.pending {
+		// Cancelled or closed by a second request meanwhile
+		s.shutdown.mu.Unlock()
+		return
+	}
+	s.shutdown.pending = false
+	s.shutdown.ready = true
+	s.shutdown.mu.Unlock()
+
+	s.broadcastShutdown("closing", policy, 0, nil)
+	utils.Logger.Info("Done waiting for running instances, closing")
+	s.StopRunning()
+	quit()
+}
+
+// StopRunning stops every instance that is still running or paused, whether or not the scheduler started it
+func (s *SchedulerService) StopRunning() {
+	names, err := model.GetAllIstNames()
+	if err != nil {
+		utils.Logger.Error("Failed to get instance names:", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		if tm := model.GetScheduler().GetTaskManager(name); tm != nil && tm.IsBusy() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.stopOne(name, nil)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// broadcastShutdown tells clients how a close of the app that waits for running instances proceeds
+func (s *SchedulerService) broadcastShutdown(state, policy string, remaining int, instances []string) {
+	if instances == nil {
+		instances = []string{}
+	}
+	utils.GetWSManager().Publish(utils.TopicShutdown, model.RspShutdown{
+		Type:      "shutdown",
+		State:     state,
+		Policy:    policy,
+		Remaining: remaining,
+		Instances: instances,
+	})
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"testing"
+	"time"
+)
+
+// useShutdownPolicy creates a running instance and sets the shutdown policy of the app
+func useShutdownPolicy(t *testing.T, policy string) *model.TaskManager {
+	t.Helper()
+	useTestDB(t)
+	timeout := 0
+	if err := model.UpdateSettings(&model.ReqUpdateSettings{ShutdownPolicy: &policy, ShutdownTimeout: &timeout}); err != nil {
+		t.Fatal(err)
+	}
+	if err := new(model.InstanceInfo).Create("game-a", "game", model.NewTplConf()); err != nil {
+		t.Fatal(err)
+	}
+	tm := model.GetScheduler().GetTaskManager("game-a")
+	if tm == nil {
+		t.Fatal("no task manager for the new instance")
+	}
+	model.GetScheduler().UpdateTaskManagerStatus("game-a", model.StatusRunning)
+	return tm
+}
+
+func TestShutdownStopsRightAway(t *testing.T) {
+	useShutdownPolicy(t, model.ShutdownStop)
+	s := &SchedulerService{}
+
+	if s.RequestShutdown(func() { t.Error("quit called for an immediate close") }) {
+		t.Fatal("RequestShutdown() kept the app open with the stop policy")
+	}
+	// Nothing starts while the app closes
+	if !s.isShuttingDown() || !s.holdForShutdown("game-a") {
+		t.Error("instances may still start tasks after the close was allowed")
+	}
+}
+
+func TestShutdownWaitsForRunningTask(t *testing.T) {
+	useShutdownPolicy(t, model.ShutdownWaitTask)
+	s := &SchedulerService{}
+
+	quit := make(chan struct{})
+	if !s.RequestShutdown(func() { close(quit) }) {
+		t.Fatal("RequestShutdown() did not wait for the running instance")
+	}
+
+	// The task finishes and the instance reaches the point where it would start the next one
+	if !s.holdForShutdown("game-a") {
+		t.Fatal("holdForShutdown() = false, the instance would start its next task")
+	}
+	select {
+	case <-quit:
+	case <-time.After(5 * time.Second):
+		t.Fatal("app not closed after the running task finished")
+	}
+	if s.RequestShutdown(func() {}) {
+		t.Error("RequestShutdown() after the wait kept the app open")
+	}
+}
+
+func TestShutdownFinishQueue(t *testing.T) {
+	useShutdownPolicy(t, model.ShutdownFinishQueue)
+	s := &SchedulerService{}
+
+	if !s.RequestShutdown(func() { t.Error("quit called after the close was cancelled") }) {
+		t.Fatal("RequestShutdown() did not wait for the running instance")
+	}
+	if s.holdForShutdown("game-a") {
+		t.Error("holdForShutdown() = true, the queue should be finished first")
+	}
+
+	s.CancelShutdown()
+	if s.isShuttingDown() || s.holdForShutdown("game-a") {
+		t.Fatal("still closing after CancelShutdown()")
+	}
+
+	// A second request while waiting closes the app right away
+	if !s.RequestShutdown(func() {}) {
+		t.Fatal("RequestShutdown() after a cancelled close did not wait")
+	}
+	if s.RequestShutdown(func() {}) {
+		t.Error("second RequestShutdown() kept the app open")
+	}
+	if !s.holdForShutdown("game-a") {
+		t.Error("holdForShutdown() = false after the close went through")
+	}
+}
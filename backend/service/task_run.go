@@ -0,0 +1,170 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"dacapo/backend/utils"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RunTask runs a single task of an idle instance right away, outside of its queue
+// Overrides map group names to item values and only apply to this run. A failed instance stays failed
+func (s *SchedulerService) RunTask(instanceName, taskName string, overrides map[string]map[string]any) (model.Status, error) {
+	tm := model.GetScheduler().GetTaskManager(instanceName)
+	if tm == nil {
+		return model.StatusDatabase, fmt.Errorf("instance not found: %s", instanceName)
+	}
+	if s.isShuttingDown() {
+		return model.StatusBusy, errors.New("the application is closing")
+	}
+
+	// Claim the instance before anything else so that a second request is refused
+	previous, ok := model.GetScheduler().ClaimIdle(instanceName)
+	if !ok {
+		return model.StatusBusy, fmt.Errorf("instance %s is %s", instanceName, previous)
+	}
+	s.UpdateInstanceStatus(instanceName, model.StatusRunning)
+	release := func() {
+		s.UpdateInstanceStatus(instanceName, previous)
+	}
+
+	var istInfo model.InstanceInfo
+	if err := istInfo.GetByName(instanceName); err != nil {
+		release()
+		return model.StatusDatabase, err
+	}
+	if istInfo.GetTaskByName(taskName) == nil {
+		release()
+		return model.StatusDatabase, fmt.Errorf("task not found: %s", taskName)
+	}
+
+	restore := func() {}
+	if len(overrides) > 0 {
+		var err error
+		if restore, err = s.applyOverrides(&istInfo, taskName, overrides); err != nil {
+			release()
+			return model.StatusOverride, err
+		}
+	}
+
+	go func() {
+		defer restore()
+		s.runSingleTask(tm, &istInfo, taskName, previous)
+	}()
+	return model.StatusSuccess, nil
+}
+
+// runSingleTask runs one task with the resources of its instance held and reports its outcome
+// The instance returns to status afterwards, a single run does not change the outcome of the last run
+func (s *SchedulerService) runSingleTask(tm *model.TaskManager, istInfo *model.InstanceInfo, taskName, status string) {
+	instanceName := istInfo.Name
+	// A stop requested while the instance was idle does not apply to this run
+	tm.ManualStop.Store(false)
+	resources := s.instanceResources(instanceName)
+	if !s.locks.TryAcquire(resources) {
+		utils.Logger.Infof("[%s]: Waiting for resources to be released", instanceName)
+		s.wsService.BroadcastLog(instanceName, "[DaCapo] Waiting for resources to be released")
+		if !s.locks.Acquire(resources, func() bool { return tm.ManualStop.Load() }) {
+			tm.ManualStop.Store(false)
+			s.UpdateInstanceStatus(instanceName, status)
+			return
+		}
+	}
+	defer s.locks.Release(resources)
+
+	tm.SetRunning(taskName)
+	s.publishQueue(instanceName)
+	s.wsService.BroadcastLog(instanceName, fmt.Sprintf("[DaCapo] Running task %s on demand", taskName))
+
+	startTime := time.Now()
+	_, err := s.runQueuedTask(tm, istInfo, taskName, resources)
+	tm.SetTaskState(taskName, taskState(err, time.Since(startTime)))
+
+	switch {
+	case err == nil:
+		utils.Logger.Infof("[%s]: task %s finished", instanceName, taskName)
+	case errors.Is(err, ErrManualStop):
+		utils.Logger.Infof("[%s]: task %s stopped manually", instanceName, taskName)
+	case errors.Is(err, ErrTaskSkipped):
+		utils.Logger.Infof("[%s]: task %s skipped: %v", instanceName, taskName, err)
+	default:
+		// A single run is a debugging aid, the instance does not enter the failed state
+		utils.Logger.Errorf("[%s]: task %s failed: %v", instanceName, taskName, err)
+		s.wsService.BroadcastLog(instanceName, err.Error())
+	}
+
+	tm.SetRunning("")
+	s.UpdateInstanceStatus(instanceName, status)
+	s.publishQueue(instanceName)
+}
+
+// overrideDir holds the configurations with overrides of single runs, beside the instance configurations
+// but outside of the watched directory itself
+var overrideDir = filepath.Join("instances", "overrides")
+
+// CleanupOverrides removes configurations with overrides left behind by a run that never finished
+// The config links themselves are restored by the link check at startup
+func CleanupOverrides() {
+	if err := os.RemoveAll(overrideDir); err != nil {
+		utils.Logger.Warnf("Failed to remove override configurations: %v", err)
+	}
+}
+
+// applyOverrides points the config path of the instance to a copy of its configuration with the
+// overrides applied. The returned function restores the link to the saved configuration
+func (s *SchedulerService) applyOverrides(istInfo *model.InstanceInfo, taskName string, overrides map[string]map[string]any) (func(), error) {
+	if istInfo.ConfigPath == "" {
+		return nil, errors.New("the instance has no config path to apply overrides to")
+	}
+	if info, err := os.Lstat(istInfo.ConfigPath); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return nil, fmt.Errorf("config path %s is not a link to the instance configuration", istInfo.ConfigPath)
+	}
+
+	istConf := model.NewIstConf()
+	if err := istConf.Load(istInfo.Name); err != nil {
+		return nil, err
+	}
+	menuName, ok := istConf.FindTask(taskName)
+	if !ok {
+		return nil, fmt.Errorf("task %s not found in instance configuration", taskName)
+	}
+	for groupName, items := range overrides {
+		for itemName, value := range items {
+			if err := istConf.Override(menuName, taskName, groupName, itemName, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := os.MkdirAll(overrideDir, 0755); err != nil {
+		return nil, err
+	}
+	tmpPath := filepath.Join(overrideDir, istInfo.Name+".json")
+	if err := istConf.SaveAs(tmpPath); err != nil {
+		return nil, err
+	}
+
+	srcPath := filepath.Join("instances", istInfo.Name+".json")
+	restore := func() {
+		if err := utils.RemoveLink(istInfo.ConfigPath); err != nil {
+			utils.Logger.Errorf("[%s]: Failed to remove temporary config link: %v", istInfo.Name, err)
+		}
+		if err := utils.CreateLink(srcPath, istInfo.ConfigPath, ""); err != nil {
+			utils.Logger.Errorf("[%s]: Failed to restore config link: %v", istInfo.Name, err)
+		}
+		os.Remove(tmpPath)
+	}
+
+	if err := utils.RemoveLink(istInfo.ConfigPath); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := utils.CreateLink(tmpPath, istInfo.ConfigPath, ""); err != nil {
+		restore()
+		return nil, err
+	}
+	return restore, nil
+}
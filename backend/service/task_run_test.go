@@ -0,0 +1,81 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"dacapo/backend/utils"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readCount reads the item overridden in TestApplyOverrides from a configuration file
+func readCount(t *testing.T, path string) float64 {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf map[string]map[string]map[string]map[string]any
+	if err := json.Unmarshal(data, &conf); err != nil {
+		t.Fatal(err)
+	}
+	count, _ := conf["Daily"]["daily"]["General"]["count"].(float64)
+	return count
+}
+
+func TestApplyOverrides(t *testing.T) {
+	useTestDB(t)
+	for _, dir := range []string{"instances", "game"} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srcPath := filepath.Join("instances", "game-a.json")
+	conf := `{"Daily": {"daily": {"General": {"count": 1, "server": "asia"}}}}`
+	if err := os.WriteFile(srcPath, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	istInfo := &model.InstanceInfo{Name: "game-a", ConfigPath: filepath.Join("game", "config.json")}
+	if err := utils.CreateLink(srcPath, istInfo.ConfigPath, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &SchedulerService{}
+	restore, err := s.applyOverrides(istInfo, "daily", map[string]map[string]any{"General": {"count": 5}})
+	if err != nil {
+		t.Fatalf("applyOverrides() error = %v", err)
+	}
+	// The program of the instance reads the overrides, the saved configuration keeps its value
+	if got := readCount(t, istInfo.ConfigPath); got != 5 {
+		t.Errorf("count read through the config path = %v, want 5", got)
+	}
+	if got := readCount(t, srcPath); got != 1 {
+		t.Errorf("count in the instance configuration = %v, want 1", got)
+	}
+
+	restore()
+	if got := readCount(t, istInfo.ConfigPath); got != 1 {
+		t.Errorf("count read through the config path after restore = %v, want 1", got)
+	}
+	if _, err := os.Stat(filepath.Join(overrideDir, "game-a.json")); !os.IsNotExist(err) {
+		t.Errorf("override configuration left behind: %v", err)
+	}
+
+	// Overrides can only change items the task has
+	if _, err := s.applyOverrides(istInfo, "daily", map[string]map[string]any{"General": {"missing": true}}); err == nil {
+		t.Error("applyOverrides() accepted an unknown item")
+	}
+	if _, err := s.applyOverrides(istInfo, "weekly", map[string]map[string]any{"General": {"count": 5}}); err == nil {
+		t.Error("applyOverrides() accepted an unknown task")
+	}
+	if got := readCount(t, istInfo.ConfigPath); got != 1 {
+		t.Errorf("count read through the config path after refused overrides = %v, want 1", got)
+	}
+
+	// A copied configuration cannot be switched for this run only
+	plain := &model.InstanceInfo{Name: "game-a", ConfigPath: srcPath}
+	if _, err := s.applyOverrides(plain, "daily", map[string]map[string]any{"General": {"count": 5}}); err == nil {
+		t.Error("applyOverrides() accepted a config path that is not a link")
+	}
+}
@@ -3,11 +3,23 @@ package service
 import (
 	"dacapo/backend/model"
 	"dacapo/backend/utils"
+	"strings"
+	"sync"
 
 	"github.com/gorilla/websocket"
 )
 
-type WebSocketService struct{}
+type WebSocketService struct {
+	logBuffers map[string]*LogBuffer // Recent log lines per instance, replayed to clients that subscribe
+	logMu      sync.Mutex
+}
+
+// NewWebSocketService creates a new WebSocket service
+func NewWebSocketService() *WebSocketService {
+	return &WebSocketService{
+		logBuffers: make(map[string]*LogBuffer),
+	}
+}
 
 // HandleConnection handles the unified WebSocket connection
 func (s *WebSocketService) HandleConnection(conn *websocket.Conn, messageHandler func(string, map[string]any)) {
@@ -17,7 +29,7 @@ func (s *WebSocketService) HandleConnection(conn *websocket.Conn, messageHandler
 	wsManager.RegisterClient(conn)
 	defer wsManager.RemoveClient(conn)
 
-	// Send initial state
+	// Send initial state, log history is replayed once the client subscribes to the log of an instance
 	s.SendQueue(conn)
 	s.SendState(conn)
 
@@ -29,6 +41,18 @@ func (s *WebSocketService) HandleConnection(conn *websocket.Conn, messageHandler
 		}
 		// Handle different message types
 		if msgType, ok := msg["type"].(string); ok {
+			switch msgType {
+			case "log_history":
+				istName, _ := msg["instance_name"].(string)
+				s.SendLogHistory(conn, istName)
+				continue
+			case "subscribe", "unsubscribe":
+				s.handleSubscription(conn, msgType, msg["topics"])
+				continue
+			case "shutdown_cancel":
+				messageHandler(msgType, nil)
+				continue
+			}
 			if data, ok := msg["data"].(map[string]any); ok {
 				// Handle app update related messages
 				if msgType == "update_confirm_response" || msgType == "restart_confirm_response" {
@@ -39,6 +63,34 @@ func (s *WebSocketService) HandleConnection(conn *websocket.Conn, messageHandler
 	}
 }
 
+// handleSubscription updates the topics a connection is subscribed to
+func (s *WebSocketService) handleSubscription(conn *websocket.Conn, msgType string, rawTopics any) {
+	items, _ := rawTopics.([]any)
+	topics := make([]string, 0, len(items))
+	for _, item := range items {
+		if topic, ok := item.(string); ok && topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+
+	wsManager := utils.GetWSManager()
+	if msgType == "unsubscribe" {
+		wsManager.Unsubscribe(conn, topics...)
+		return
+	}
+
+	wsManager.Subscribe(conn, topics...)
+	// Replay recent logs for newly subscribed instances, "log:*" replays all of them
+	for _, topic := range topics {
+		if istName, ok := strings.CutPrefix(topic, utils.LogTopic("")); ok {
+			if istName == "*" {
+				istName = ""
+			}
+			s.SendLogHistory(conn, istName)
+		}
+	}
+}
+
 // SendQueue sends the task queue to a specific connection
 func (s *WebSocketService) SendQueue(conn *websocket.Conn) {
 	scheduler := model.GetScheduler()
@@ -76,6 +128,50 @@ func (s *WebSocketService) SendState(conn *websocket.Conn) {
 	utils.GetWSManager().SendJSON(conn, schedulerState)
 }
 
+// SendLogHistory sends buffered log lines of an instance to a specific connection
+// If istName is empty, the history of all instances is sent
+func (s *WebSocketService) SendLogHistory(conn *websocket.Conn, istName string) {
+	s.logMu.Lock()
+	buffers := make(map[string]*LogBuffer, len(s.logBuffers))
+	for name, buffer := range s.logBuffers {
+		if istName == "" || name == istName {
+			buffers[name] = buffer
+		}
+	}
+	s.logMu.Unlock()
+
+	for name, buffer := range buffers {
+		history := model.RspLogHistory{
+			Type:         "log_history",
+			InstanceName: name,
+			Lines:        buffer.Lines(),
+		}
+		if err := utils.GetWSManager().SendJSON(conn, history); err != nil {
+			return
+		}
+	}
+}
+
+// getLogBuffer returns the log buffer of an instance, creating it if necessary
+func (s *WebSocketService) getLogBuffer(istName string) *LogBuffer {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	buffer, ok := s.logBuffers[istName]
+	if !ok {
+		buffer = NewLogBuffer(LogBufferSize)
+		s.logBuffers[istName] = buffer
+	}
+	return buffer
+}
+
+// RemoveLogBuffer forgets the recent log lines of an instance that no longer exists
+func (s *WebSocketService) RemoveLogBuffer(istName string) {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	delete(s.logBuffers, istName)
+}
+
 // BroadcastQueue broadcasts queue updates
 func (s *WebSocketService) BroadcastQueue(istName string) {
 	scheduler := model.GetScheduler()
@@ -86,9 +182,9 @@ func (s *WebSocketService) BroadcastQueue(istName string) {
 	update := model.RspTaskQueue{
 		Type:         "queue",
 		InstanceName: istName,
-		Queue:        tm.Queue,
+		Queue:        tm.QueueSnapshot(),
 	}
-	utils.GetWSManager().BroadcastJSON(update)
+	utils.GetWSManager().Publish(utils.TopicQueue, update)
 }
 
 // BroadcastState broadcasts scheduler state
@@ -98,15 +194,17 @@ func (s *WebSocketService) BroadcastState(istName, state string) {
 		InstanceName: istName,
 		State:        state,
 	}
-	utils.GetWSManager().BroadcastJSON(message)
+	utils.GetWSManager().Publish(utils.TopicState, message)
 }
 
-// BroadcastLog broadcasts log messages
+// BroadcastLog broadcasts log messages and keeps them for replay
 func (s *WebSocketService) BroadcastLog(istName, content string) {
+	s.getLogBuffer(istName).Add(content)
+
 	message := model.RspLogMessage{
 		Type:         "log",
 		InstanceName: istName,
 		Content:      content,
 	}
-	utils.GetWSManager().BroadcastJSON(message)
+	utils.GetWSManager().Publish(utils.LogTopic(istName), message)
 }
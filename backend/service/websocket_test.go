@@ -0,0 +1,172 @@
+package service
+
+import (
+	"dacapo/backend/model"
+	"dacapo/backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// useTestDB runs a test in an empty working directory with a fresh database and settings file
+func useTestDB(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	// Set once, connections of earlier tests may still be logging
+	if utils.Logger == nil {
+		utils.Logger = zap.NewNop().Sugar()
+	}
+	model.InitDB()
+
+	t.Cleanup(func() {
+		model.CloseDB()
+		os.Chdir(wd)
+	})
+}
+
+// dialWS connects a client to a test server that handles connections with s
+func dialWS(t *testing.T, s *WebSocketService) *websocket.Conn {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.HandleConnection(conn, func(string, map[string]any) {})
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readWS reads the next message, failing the test if none arrives in time
+func readWS(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message map[string]any
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("no message received: %v", err)
+	}
+	return message
+}
+
+// readInitialState skips the messages sent on connect, which end with the state of the scheduler
+func readInitialState(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+	for {
+		message := readWS(t, conn)
+		if message["type"] == "log_history" {
+			t.Fatal("log history was sent before the client subscribed")
+		}
+		if message["type"] == "state" && message["instance_name"] == "" {
+			return
+		}
+	}
+}
+
+func TestRemoveLogBuffer(t *testing.T) {
+	s := NewWebSocketService()
+	s.BroadcastLog("game-a", "first run")
+	s.BroadcastLog("game-b", "kept")
+
+	s.RemoveLogBuffer("game-a")
+	if _, ok := s.logBuffers["game-a"]; ok {
+		t.Fatal("log buffer of game-a is still kept after it was removed")
+	}
+
+	// A new instance with the same name starts without the old lines
+	s.BroadcastLog("game-a", "second run")
+	if got := s.getLogBuffer("game-a").Lines(); len(got) != 1 || got[0] != "second run" {
+		t.Errorf("lines of game-a = %v, want [second run]", got)
+	}
+	if got := s.getLogBuffer("game-b").Lines(); len(got) != 1 || got[0] != "kept" {
+		t.Errorf("lines of game-b = %v, want [kept]", got)
+	}
+}
+
+func TestLogHistoryReplayedOnSubscribe(t *testing.T) {
+	useTestDB(t)
+	s := NewWebSocketService()
+	s.BroadcastLog("game-a", "[DaCapo] Running task daily")
+	s.BroadcastLog("game-a", "daily done")
+
+	conn := dialWS(t, s)
+	readInitialState(t, conn)
+
+	conn.WriteJSON(map[string]any{"type": "subscribe", "topics": []string{utils.LogTopic("game-a")}})
+	history := readWS(t, conn)
+	lines, _ := history["lines"].([]any)
+	if history["type"] != "log_history" || history["instance_name"] != "game-a" || len(lines) != 2 || lines[1] != "daily done" {
+		t.Fatalf("reply to subscribe = %v, want the two buffered lines of game-a", history)
+	}
+
+	// Only new lines follow, the history is not sent a second time
+	s.BroadcastLog("game-a", "weekly done")
+	if message := readWS(t, conn); message["type"] != "log" || message["content"] != "weekly done" {
+		t.Errorf("next message = %v, want the new line", message)
+	}
+}
+
+func TestTopicRouting(t *testing.T) {
+	useTestDB(t)
+	s := NewWebSocketService()
+	conn := dialWS(t, s)
+	readInitialState(t, conn)
+
+	// Until it subscribes, a client receives every topic
+	s.BroadcastState("game-b", model.StatusRunning)
+	if message := readWS(t, conn); message["type"] != "state" || message["instance_name"] != "game-b" {
+		t.Fatalf("message before subscribing = %v, want the state of game-b", message)
+	}
+
+	s.BroadcastLog("game-a", "ready") // Buffered so that the subscription is answered
+	readWS(t, conn)
+	conn.WriteJSON(map[string]any{"type": "subscribe", "topics": []string{utils.TopicState, utils.LogTopic("game-a")}})
+	if message := readWS(t, conn); message["type"] != "log_history" {
+		t.Fatalf("reply to subscribe = %v, want the log history", message)
+	}
+
+	// Messages of other topics are not delivered, the order of the rest is kept
+	s.BroadcastLog("game-b", "not subscribed")
+	utils.GetWSManager().Publish(utils.TopicUpdate, map[string]any{"type": "update"})
+	s.BroadcastLog("game-a", "first")
+	s.BroadcastState("game-a", model.StatusPaused)
+	for _, want := range []string{"first", model.StatusPaused} {
+		message := readWS(t, conn)
+		if message["content"] != want && message["state"] != want {
+			t.Errorf("message = %v, want %s", message, want)
+		}
+	}
+
+	conn.WriteJSON(map[string]any{"type": "unsubscribe", "topics": []string{utils.TopicState}})
+	conn.WriteJSON(map[string]any{"type": "subscribe", "topics": []string{"log:*"}})
+	// The wildcard replays the buffered lines of every instance
+	for range 2 {
+		if message := readWS(t, conn); message["type"] != "log_history" {
+			t.Fatalf("reply to subscribe = %v, want the log history", message)
+		}
+	}
+	s.BroadcastState("game-a", model.StatusPending)
+	s.BroadcastLog("game-c", "any instance")
+	if message := readWS(t, conn); message["content"] != "any instance" {
+		t.Errorf("message = %v, want the log line of game-c", message)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build !windows
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// SetProcessGroup starts the command in its own process group so that its children can be terminated together
+func SetProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+}
+
+// TerminateProcessTree sends SIGTERM to the process group of pid, waits up to grace for it to exit, then sends SIGKILL
+func TerminateProcessTree(pid int, grace time.Duration) error {
+	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return nil
+		}
+		return err
+	}
+	// Suspended processes only handle SIGTERM once they continue
+	_ = syscall.Kill(-pid, syscall.SIGCONT)
+
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		// Signal 0 only checks whether any process of the group is still alive
+		if err := syscall.Kill(-pid, 0); errors.Is(err, syscall.ESRCH) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	Logger.Warnf("Process group %d did not exit within %v, killing", pid, grace)
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// SuspendProcessTree stops every process in the process group of pid
+func SuspendProcessTree(pid int) error {
+	return signalGroup(pid, syscall.SIGSTOP)
+}
+
+// ResumeProcessTree continues every process in the process group of pid
+func ResumeProcessTree(pid int) error {
+	return signalGroup(pid, syscall.SIGCONT)
+}
+
+// signalGroup sends a signal to the process group of pid, a group that already exited is not an error
+func signalGroup(pid int, sig syscall.Signal) error {
+	if err := syscall.Kill(-pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// ProcessStartTime returns the creation time of a running process, with a resolution of one second
+func ProcessStartTime(pid int) (time.Time, error) {
+	if err := syscall.Kill(pid, 0); err != nil && !errors.Is(err, syscall.EPERM) {
+		return time.Time{}, err
+	}
+
+	output, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query process %d: %w", pid, err)
+	}
+	// The output looks like "Mon Jan  2 15:04:05 2006" in local time
+	fields := strings.Fields(string(output))
+	return time.ParseInLocation("Mon Jan 2 15:04:05 2006", strings.Join(fields, " "), time.Local)
+}
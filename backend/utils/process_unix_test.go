@@ -0,0 +1,135 @@
+//go:build linux
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// startGroup runs a shell script in its own process group and returns the PID of the background
+// process it prints first. The shell is waited for in the background, as RunCommand does
+func startGroup(t *testing.T, script string) (*exec.Cmd, int, chan struct{}) {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", script)
+	SetProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() { signalGroup(cmd.Process.Pid, syscall.SIGKILL) })
+	return cmd, child, exited
+}
+
+// processState returns the state letter of a process, empty if it does not exist
+func processState(pid int) string {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return ""
+	}
+	// The state follows the command name in parentheses
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// running reports whether a process exists and is not a zombie waiting to be reaped
+func running(pid int) bool {
+	state := processState(pid)
+	return state != "" && state != "Z"
+}
+
+func TestTerminateProcessTree(t *testing.T) {
+	Logger = zap.NewNop().Sugar()
+
+	t.Run("children are terminated with the task", func(t *testing.T) {
+		cmd, child, exited := startGroup(t, "sleep 30 & echo $!; wait")
+		if err := TerminateProcessTree(cmd.Process.Pid, 5*time.Second); err != nil {
+			t.Fatalf("TerminateProcessTree() error = %v", err)
+		}
+		<-exited
+		if running(child) {
+			t.Errorf("child process %d is still running", child)
+		}
+	})
+
+	t.Run("killed after the grace period", func(t *testing.T) {
+		// The ignored SIGTERM is inherited by the child
+		cmd, child, exited := startGroup(t, `trap "" TERM; sleep 30 & echo $!; wait`)
+		grace := 300 * time.Millisecond
+		start := time.Now()
+		if err := TerminateProcessTree(cmd.Process.Pid, grace); err != nil {
+			t.Fatalf("TerminateProcessTree() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < grace {
+			t.Errorf("killed after %v, before the grace period of %v", elapsed, grace)
+		}
+		<-exited
+		if running(child) {
+			t.Errorf("child process %d is still running", child)
+		}
+	})
+
+	t.Run("group that already exited", func(t *testing.T) {
+		cmd, _, exited := startGroup(t, "echo 0")
+		<-exited
+		if err := TerminateProcessTree(cmd.Process.Pid, time.Second); err != nil {
+			t.Errorf("TerminateProcessTree() error = %v for an exited group", err)
+		}
+	})
+}
+
+func TestSuspendProcessTree(t *testing.T) {
+	cmd, child, _ := startGroup(t, "sleep 30 & echo $!; wait")
+
+	if err := SuspendProcessTree(cmd.Process.Pid); err != nil {
+		t.Fatalf("SuspendProcessTree() error = %v", err)
+	}
+	// Signals are delivered asynchronously
+	waitState(t, child, "T")
+
+	if err := ResumeProcessTree(cmd.Process.Pid); err != nil {
+		t.Fatalf("ResumeProcessTree() error = %v", err)
+	}
+	waitState(t, child, "S")
+}
+
+// waitState waits until a process reaches the given state
+func waitState(t *testing.T, pid int, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for processState(pid) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("process %d is in state %q, want %q", pid, processState(pid), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
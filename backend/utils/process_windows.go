@@ -0,0 +1,156 @@
+//go:build windows
+
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// SetProcessGroup hides the console window of the command
+// Windows has no process groups to signal, the tree is terminated through taskkill instead
+func SetProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: 0x08000000, // CREATE_NO_WINDOW
+	}
+}
+
+// TerminateProcessTree asks the process tree of pid to close, waits up to grace, then forcibly terminates it
+func TerminateProcessTree(pid int, grace time.Duration) error {
+	pidStr := strconv.Itoa(pid)
+	if err := runTaskkill("/T", "/PID", pidStr); err != nil {
+		// Console programs ignore the close request, go straight to force termination
+		return runTaskkill("/T", "/F", "/PID", pidStr)
+	}
+
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if !processExists(pidStr) {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	Logger.Warnf("Process tree %d did not exit within %v, killing", pid, grace)
+	return runTaskkill("/T", "/F", "/PID", pidStr)
+}
+
+// runTaskkill runs taskkill with the given arguments without showing a window
+func runTaskkill(args ...string) error {
+	cmd := exec.Command("taskkill", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: 0x08000000,
+	}
+	return cmd.Run()
+}
+
+// processExists checks whether a process with the given PID is running
+func processExists(pidStr string) bool {
+	cmd := exec.Command("tasklist", "/NH", "/FI", "PID eq "+pidStr)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: 0x08000000,
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	// tasklist prints an info line instead of a table row when nothing matches
+	return strings.Contains(string(output), " "+pidStr+" ")
+}
+
+var (
+	ntdll            = syscall.NewLazyDLL("ntdll.dll")
+	ntSuspendProcess = ntdll.NewProc("NtSuspendProcess")
+	ntResumeProcess  = ntdll.NewProc("NtResumeProcess")
+)
+
+// SuspendProcessTree suspends pid and all of its descendants
+func SuspendProcessTree(pid int) error {
+	return forEachInTree(pid, ntSuspendProcess)
+}
+
+// ResumeProcessTree resumes pid and all of its descendants
+func ResumeProcessTree(pid int) error {
+	return forEachInTree(pid, ntResumeProcess)
+}
+
+// forEachInTree calls an ntdll process function on pid and all of its descendants
+func forEachInTree(pid int, proc *syscall.LazyProc) error {
+	pids, err := processTree(uint32(pid))
+	if err != nil {
+		return err
+	}
+
+	const processSuspendResume = 0x0800
+	for _, id := range pids {
+		handle, err := syscall.OpenProcess(processSuspendResume, false, id)
+		if err != nil {
+			continue // Already exited
+		}
+		if status, _, _ := proc.Call(uintptr(handle)); status != 0 {
+			Logger.Warnf("%s failed for process %d: NTSTATUS 0x%x", proc.Name, id, status)
+		}
+		syscall.CloseHandle(handle)
+	}
+	return nil
+}
+
+// processTree returns pid followed by all of its descendants
+func processTree(pid uint32) ([]uint32, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	children := make(map[uint32][]uint32)
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = syscall.Process32First(snapshot, &entry); err == nil; err = syscall.Process32Next(snapshot, &entry) {
+		children[entry.ParentProcessID] = append(children[entry.ParentProcessID], entry.ProcessID)
+	}
+
+	tree := []uint32{pid}
+	for i := 0; i < len(tree); i++ {
+		for _, child := range children[tree[i]] {
+			if child != tree[i] && !slices.Contains(tree, child) {
+				tree = append(tree, child)
+			}
+		}
+	}
+	return tree, nil
+}
+
+// ProcessStartTime returns the creation time of a running process
+func ProcessStartTime(pid int) (time.Time, error) {
+	const processQueryLimitedInformation = 0x1000
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer syscall.CloseHandle(handle)
+
+	// An exited process can be opened as long as a handle to it is open somewhere
+	const stillActive = 259
+	var exitCode uint32
+	if err := syscall.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return time.Time{}, err
+	}
+	if exitCode != stillActive {
+		return time.Time{}, fmt.Errorf("process %d has exited", pid)
+	}
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, creation.Nanoseconds()), nil
+}
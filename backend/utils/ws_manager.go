@@ -1,14 +1,152 @@
 package utils
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// WebSocket client settings
+const (
+	wsSendQueueSize = 256              // Maximum pending messages per client, older ones are dropped
+	wsWriteWait     = 10 * time.Second // Time allowed to write a message
+	wsPongWait      = 60 * time.Second // Time allowed to read the next pong
+	wsPingPeriod    = 50 * time.Second // Must be less than wsPongWait
+)
+
+// WebSocket topics, log messages use LogTopic per instance
+const (
+	TopicQueue      = "queue"
+	TopicState      = "state"
+	TopicUpdate     = "update"
+	TopicFileChange = "file_change"
+	TopicShutdown   = "shutdown"
+)
+
+var ErrWSClientGone = errors.New("websocket client is not connected")
+
+// LogTopic returns the topic of log messages for an instance
+func LogTopic(instanceName string) string {
+	return "log:" + instanceName
+}
+
+// wsMessage is a queued outbound message
+type wsMessage struct {
+	payload any
+	log     bool // Log lines are dropped first when the queue is full
+}
+
+// wsClient owns a connection and its outbound queue; only its writer goroutine writes to the connection
+type wsClient struct {
+	conn      *websocket.Conn
+	queue     []wsMessage
+	queueMu   sync.Mutex
+	ready     chan struct{} // Signalled when a message is queued
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// Clients receive every topic until they subscribe for the first time
+	filtered bool
+	topics   map[string]bool
+}
+
+// wants reports whether the client is subscribed to a topic, "<kind>:*" matches all topics of a kind
+func (c *wsClient) wants(topic string) bool {
+	if !c.filtered || c.topics[topic] {
+		return true
+	}
+	if kind, _, ok := strings.Cut(topic, ":"); ok {
+		return c.topics[kind+":*"]
+	}
+	return false
+}
+
+// close stops the writer goroutine, which then closes the connection
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// enqueue queues a message without blocking, returning false if the client is closed
+// A full queue drops its oldest log line, or its oldest message if it holds no log lines, so that a slow
+// client falls behind on output instead of being disconnected
+func (c *wsClient) enqueue(message any, log bool) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	c.queueMu.Lock()
+	if len(c.queue) >= wsSendQueueSize {
+		i := slices.IndexFunc(c.queue, func(m wsMessage) bool { return m.log })
+		if i < 0 {
+			i = 0
+			Logger.Warnf("WebSocket client %s is not keeping up, dropping a message", c.conn.RemoteAddr())
+		}
+		c.queue = slices.Delete(c.queue, i, i+1)
+	}
+	c.queue = append(c.queue, wsMessage{payload: message, log: log})
+	c.queueMu.Unlock()
+
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// dequeue takes the oldest queued message
+func (c *wsClient) dequeue() (any, bool) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	if len(c.queue) == 0 {
+		return nil, false
+	}
+	message := c.queue[0]
+	c.queue = slices.Delete(c.queue, 0, 1)
+	return message.payload, true
+}
+
+// writeLoop sends queued messages and keepalive pings until the client is closed
+func (c *wsClient) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.ready:
+			for message, ok := c.dequeue(); ok; message, ok = c.dequeue() {
+				c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+				if err := c.conn.WriteJSON(message); err != nil {
+					Logger.Errorf("Failed to send WebSocket message to %s: %v", c.conn.RemoteAddr(), err)
+					c.close()
+					return
+				}
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				Logger.Warnf("WebSocket ping to %s failed: %v", c.conn.RemoteAddr(), err)
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
 // WSManager manages all WebSocket connections
 type WSManager struct {
-	clients map[*websocket.Conn]bool
+	clients map[*websocket.Conn]*wsClient
 	mu      sync.Mutex // Mutex for thread safety
 }
 
@@ -23,59 +161,110 @@ func GetWSManager() *WSManager {
 	defer initMutex.Unlock()
 	if wsManager == nil {
 		wsManager = &WSManager{
-			clients: make(map[*websocket.Conn]bool),
+			clients: make(map[*websocket.Conn]*wsClient),
 		}
 	}
 	return wsManager
 }
 
-// RegisterClient registers a new WebSocket connection
+// RegisterClient registers a new WebSocket connection and starts its writer
+// The caller must keep reading from the connection so that pongs are processed
 func (m *WSManager) RegisterClient(conn *websocket.Conn) {
+	client := &wsClient{
+		conn:   conn,
+		ready:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		topics: make(map[string]bool),
+	}
+
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.clients[conn] = true
-	Logger.Infof("New WebSocket client %s registered, total: %d", conn.RemoteAddr(), len(m.clients))
+	m.clients[conn] = client
+	total := len(m.clients)
+	m.mu.Unlock()
+
+	go client.writeLoop()
+	Logger.Infof("New WebSocket client %s registered, total: %d", conn.RemoteAddr(), total)
 }
 
 // RemoveClient removes a WebSocket connection
 func (m *WSManager) RemoveClient(conn *websocket.Conn) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	client, ok := m.clients[conn]
 	delete(m.clients, conn)
-	Logger.Infof("WebSocket client %s removed, total: %d", conn.RemoteAddr(), len(m.clients))
+	total := len(m.clients)
+	m.mu.Unlock()
+
+	if ok {
+		client.close()
+	}
+	Logger.Infof("WebSocket client %s removed, total: %d", conn.RemoteAddr(), total)
 }
 
-// BroadcastJSON broadcasts JSON message to all clients
-func (m *WSManager) BroadcastJSON(message any) {
+// Subscribe adds topics to a client, switching it to receive subscribed topics only
+func (m *WSManager) Subscribe(conn *websocket.Conn, topics ...string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	var failedClients []*websocket.Conn
-	for conn := range m.clients {
-		if err := conn.WriteJSON(message); err != nil {
-			Logger.Errorf("Failed to send WebSocket message to %s: %v", conn.RemoteAddr(), err)
-			failedClients = append(failedClients, conn)
+	if client, ok := m.clients[conn]; ok {
+		client.filtered = true
+		for _, topic := range topics {
+			client.topics[topic] = true
 		}
 	}
+}
+
+// Unsubscribe removes topics from a client
+func (m *WSManager) Unsubscribe(conn *websocket.Conn, topics ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	// Clean up failed connections
-	for _, conn := range failedClients {
-		conn.Close()
-		delete(m.clients, conn)
+	if client, ok := m.clients[conn]; ok {
+		client.filtered = true
+		for _, topic := range topics {
+			delete(client.topics, topic)
+		}
 	}
+}
+
+// IsSubscribed reports whether a client receives messages of a topic
+func (m *WSManager) IsSubscribed(conn *websocket.Conn, topic string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	client, ok := m.clients[conn]
+	return ok && client.wants(topic)
+}
 
-	if len(failedClients) > 0 {
-		Logger.Infof("Cleaned up %d failed WebSocket connections, remaining: %d", len(failedClients), len(m.clients))
+// Publish queues a JSON message for the clients subscribed to a topic without blocking
+// Clients are only disconnected when writing to them fails
+func (m *WSManager) Publish(topic string, message any) {
+	log := strings.HasPrefix(topic, LogTopic(""))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, client := range m.clients {
+		if client.wants(topic) {
+			client.enqueue(message, log)
+		}
 	}
 }
 
-// SendJSON sends JSON message to a specific client
+// SendJSON queues a JSON message for a specific client without blocking
 func (m *WSManager) SendJSON(conn *websocket.Conn, message any) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	if err := conn.WriteJSON(message); err != nil {
-		Logger.Errorf("Failed to send WebSocket message to %s: %v", conn.RemoteAddr(), err)
-		return err
+	client, ok := m.clients[conn]
+	m.mu.Unlock()
+	if !ok {
+		return ErrWSClientGone
+	}
+
+	if !client.enqueue(message, false) {
+		return ErrWSClientGone
 	}
 	return nil
 }
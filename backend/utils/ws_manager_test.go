@@ -0,0 +1,61 @@
+package utils
+
+import "testing"
+
+func TestWSClientWants(t *testing.T) {
+	client := &wsClient{topics: make(map[string]bool)}
+	if !client.wants(TopicQueue) || !client.wants(LogTopic("game-a")) {
+		t.Fatal("a client that never subscribed should receive every topic")
+	}
+
+	client.filtered = true
+	client.topics[TopicQueue] = true
+	client.topics[LogTopic("game-a")] = true
+	for topic, want := range map[string]bool{
+		TopicQueue:          true,
+		TopicState:          false,
+		LogTopic("game-a"):  true,
+		LogTopic("game-b"):  false,
+		LogTopic("game-a:"): false,
+	} {
+		if got := client.wants(topic); got != want {
+			t.Errorf("wants(%q) = %v, want %v", topic, got, want)
+		}
+	}
+
+	client.topics["log:*"] = true
+	if !client.wants(LogTopic("game-b")) {
+		t.Error("log:* should match the log topic of every instance")
+	}
+}
+
+func TestWSClientQueueDropsOldestLogLines(t *testing.T) {
+	client := &wsClient{ready: make(chan struct{}, 1), done: make(chan struct{})}
+
+	client.enqueue("state", false)
+	for i := range wsSendQueueSize + 10 {
+		if !client.enqueue(i, true) {
+			t.Fatal("enqueue() = false on a full queue, the client should stay connected")
+		}
+	}
+
+	// The state message survives and the newest log lines are kept in order
+	want := []any{"state"}
+	for i := 11; i < wsSendQueueSize+10; i++ {
+		want = append(want, i)
+	}
+	for i, w := range want {
+		got, ok := client.dequeue()
+		if !ok || got != w {
+			t.Fatalf("message %d = %v, %v, want %v", i, got, ok, w)
+		}
+	}
+	if _, ok := client.dequeue(); ok {
+		t.Error("queue holds more messages than its size")
+	}
+
+	client.close()
+	if client.enqueue("state", false) {
+		t.Error("enqueue() = true after the client was closed")
+	}
+}
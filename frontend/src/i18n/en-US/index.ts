@@ -19,6 +19,15 @@ export default {
     waiting: 'Waiting',
     stopped: 'Stopped',
     logs: 'Logs',
+    taskState: {
+      succeeded: 'Succeeded',
+      failed: 'Failed',
+      skipped: 'Skipped',
+      timed_out: 'Timed out',
+      cancelled: 'Cancelled',
+    },
+    duration: 'Duration',
+    exitCode: 'Exit code',
   },
   general: {
     title: 'Basic Settings',
@@ -28,6 +37,16 @@ export default {
     configPath: 'Config Path',
     logPath: 'Log Path',
     cronExpr: 'Cron Expression',
+    taskTimeout: 'Task Timeout',
+    catchUp: 'Catch Up',
+    execWindow: 'Execution Window',
+    windowPolicy: 'Window Closing',
+    timezone: 'Timezone',
+    cronJitter: 'Start Jitter',
+    resources: 'Resources',
+    dependsOn: 'Depends On',
+    dependencyPolicy: 'On Dependency Failure',
+    onFailure: 'On Task Failure',
     help: {
       language: 'The language displayed in this instance',
       workDir:
@@ -39,7 +58,27 @@ export default {
       logPath:
         'Absolute path(or path relative to the project root) of the log directory',
       cronExpr:
-        'Cron expression for scheduled tasks, leave empty to disable automatic execution\nSee rules at https://en.wikipedia.org/wiki/Cron\nFor example, to run a task daily at 6 PM, enter "0 18 * * *"',
+        'Cron expression for scheduled tasks, leave empty to disable automatic execution\nSee rules at https://en.wikipedia.org/wiki/Cron\nFor example, to run a task daily at 6 PM, enter "0 18 * * *"\nDescriptors such as "@daily" or "@every 2h" are also supported, a seconds field is not',
+      taskTimeout:
+        'Default time limit for each task in minutes, a task running longer is terminated and counted as a timeout failure, 0 means no limit',
+      catchUp:
+        'What to do on startup with scheduled runs missed while DaCapo was closed: once runs them a single time, all runs every missed time, skip ignores them',
+      execWindow:
+        'Time windows in which tasks may start, e.g. "04:00-23:30", separate several windows with commas and prefix a weekday to limit a window to that day, e.g. "sat 08:00-12:00", leave empty to allow any time',
+      windowPolicy:
+        'What happens to a running task when the execution window closes: finish lets it complete without starting the next task, stop terminates it and runs it again in the next window',
+      timezone:
+        'IANA timezone of the cron expression and execution windows, e.g. "Asia/Tokyo", leave empty to use the local timezone',
+      cronJitter:
+        'Scheduled starts are delayed by a random time of up to this many minutes, 0 starts exactly on time',
+      resources:
+        'Named resources this instance holds while it runs, e.g. "emulator-5555, gpu:2", the number after the colon is how many holders may use the resource at once (default 1), resource_capacity in settings.yml takes precedence\nInstances that are not background programs also hold the "foreground" resource, so they never run at the same time',
+      dependsOn:
+        'Instances that must finish before this one starts in a scheduled run, separated by commas, e.g. "game-a, game-b"',
+      dependencyPolicy:
+        'What to do when a dependency fails: skip does not run this instance, run starts it anyway',
+      onFailure:
+        'What happens when a task fails: stop_instance retries it and then stops the instance, continue moves on to the next task without retrying, retry_then_continue retries it and then moves on; tasks that depend on a failed task are skipped',
     },
   },
   update: {
@@ -74,10 +113,39 @@ export default {
     active: 'Active',
     priority: 'Priority',
     command: 'Command',
+    timeout: 'Timeout',
+    maxRetries: 'Max Retries',
+    retryDelay: 'Retry Delay',
+    retryBackoff: 'Retry Backoff',
+    resources: 'Resources',
+    dependsOn: 'Depends On',
+    runIfFailed: 'Run If Failed',
+    always: 'Always Run',
+    onFailure: 'On Failure',
+    exitCodes: 'Exit Codes',
     help: {
       active: 'Whether this task will be added to the task queue',
       priority: '0-31, higher number means higher priority',
       command: 'Command to execute this task',
+      timeout:
+        'Time limit of this task in minutes, 0 uses the instance default',
+      maxRetries:
+        'How many times a failed run of this task is retried before the instance is marked as failed',
+      retryDelay: 'Seconds to wait before the first retry',
+      retryBackoff:
+        'How the delay grows between retries: fixed keeps it, linear multiplies it by the retry number, exponential doubles it each time',
+      resources:
+        'Named resources held only while this task runs, e.g. "gpu", the task waits until they are free',
+      dependsOn:
+        'Tasks of this instance that must succeed before this one runs, separated by commas, e.g. "login"; if one of them fails this task is skipped',
+      runIfFailed:
+        'Cleanup task that only runs after another task of this instance failed',
+      always:
+        'Cleanup task that runs even after another task of this instance failed, e.g. logging out',
+      onFailure:
+        'What happens when this task fails, default uses the policy of the instance',
+      exitCodes:
+        'What exit codes of this task mean, e.g. "3:skipped, 75:retry"; success and failure count as such, skipped means there was nothing to do, retry runs the task again at least once; unlisted non-zero codes are failures',
     },
   },
   settings: {
@@ -147,4 +215,15 @@ export default {
     restartLater: 'Restart Later',
     restarting: 'Restarting application...',
   },
+  shutdown: {
+    title: 'Closing',
+    waitTask:
+      'Waiting for the running tasks to finish, no further task will be started.',
+    finishQueue: 'Waiting for the running instances to finish their queues.',
+    instances: 'Running: {names}',
+    remaining: 'Remaining tasks will be stopped in {seconds} seconds',
+    noLimit: 'Closing once they are done, close again to stop them now',
+    closing: 'Closing the application...',
+    cancel: 'Cancel Close',
+  },
 };
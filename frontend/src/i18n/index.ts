@@ -7,6 +7,7 @@ export default {
 };
 
 
+import { useI18n } from 'vue-i18n';
 import { useIstStore } from '../stores/global-store';
 
 export function useTranslation(instanceName: string) {
@@ -49,4 +50,28 @@ export function useTranslation(instanceName: string) {
     getItemHelp,
     getItemOption,
   };
-}
\ No newline at end of file
+}
+
+export function useBaseItemI18n() {
+  const { t, te } = useI18n();
+
+  // Built-in _Base items are translated under <section>.<camelCaseName>
+  const toKey = (itemName: string) => {
+    return itemName.replace(/_([a-z])/g, (_, c: string) => c.toUpperCase());
+  };
+
+  const baseItemLabel = (section: string, itemName: string) => {
+    const key = `${section}.${toKey(itemName)}`;
+    return te(key) ? t(key) : itemName;
+  };
+
+  const baseItemHelp = (section: string, itemName: string) => {
+    const key = `${section}.help.${toKey(itemName)}`;
+    return te(key) ? t(key) : undefined;
+  };
+
+  return {
+    baseItemLabel,
+    baseItemHelp,
+  };
+}
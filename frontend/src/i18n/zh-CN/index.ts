@@ -17,6 +17,15 @@ export default {
     waiting: '等待',
     stopped: '终止',
     logs: '日志',
+    taskState: {
+      succeeded: '成功',
+      failed: '失败',
+      skipped: '跳过',
+      timed_out: '超时',
+      cancelled: '已取消',
+    },
+    duration: '耗时',
+    exitCode: '退出码',
   },
   general: {
     title: '基本设置',
@@ -26,6 +35,16 @@ export default {
     configPath: '配置路径',
     logPath: '日志路径',
     cronExpr: 'Cron表达式',
+    taskTimeout: '任务超时',
+    catchUp: '补跑策略',
+    execWindow: '运行时段',
+    windowPolicy: '时段结束',
+    timezone: '时区',
+    cronJitter: '随机延迟',
+    resources: '占用资源',
+    dependsOn: '依赖实例',
+    dependencyPolicy: '依赖失败时',
+    onFailure: '任务失败时',
     help: {
       language: '此实例显示的语言',
       workDir: '程序的工作目录，通常应该是项目根目录',
@@ -33,7 +52,26 @@ export default {
       configPath: '程序访问JSON配置文件的位置，具体到文件名',
       logPath: '日志所在目录的绝对路径，或相对于项目根目录的路径',
       cronExpr:
-        '定时任务Cron表达式，留空不自动运行\n规则见https://help.aliyun.com/zh/ecs/use-cases/cron-scheduled-tasks\n例如每天18点执行任务，填写“0 18 * * *”',
+        '定时任务Cron表达式，留空不自动运行\n规则见https://help.aliyun.com/zh/ecs/use-cases/cron-scheduled-tasks\n例如每天18点执行任务，填写“0 18 * * *”\n也支持“@daily”“@every 2h”等描述符，不支持秒字段',
+      taskTimeout:
+        '每个任务默认的运行时限（分钟），超时的任务会被终止并记为超时失败，0表示不限制',
+      catchUp:
+        '启动时如何处理DaCapo关闭期间错过的定时运行：once补跑一次，all每次错过都补跑，skip忽略',
+      execWindow:
+        '允许启动任务的时段，例如“04:00-23:30”，多个时段用逗号分隔，加上星期前缀可限定到某天，例如“sat 08:00-12:00”，留空表示不限制',
+      windowPolicy:
+        '运行时段结束时如何处理正在运行的任务：finish让其运行完毕但不再启动后续任务，stop终止任务并在下个时段重新运行',
+      timezone:
+        '定时表达式和运行时段所用的IANA时区，例如“Asia/Tokyo”，留空使用本地时区',
+      cronJitter: '定时启动会随机延迟不超过此分钟数的时间，0表示准时启动',
+      resources:
+        '实例运行期间占用的命名资源，例如“emulator-5555, gpu:2”，冒号后的数字为可同时占用该资源的数量（默认1），settings.yml中的resource_capacity优先\n非后台程序的实例还会占用“foreground”资源，因此它们不会同时运行',
+      dependsOn:
+        '定时运行时需先完成的实例，多个用逗号分隔，例如“game-a, game-b”',
+      dependencyPolicy:
+        '依赖实例失败时的处理方式：skip跳过本实例，run照常运行',
+      onFailure:
+        '任务失败时的处理方式：stop_instance重试后停止实例，continue不重试直接执行下一个任务，retry_then_continue重试后继续执行下一个任务；依赖失败任务的任务会被跳过',
     },
   },
   update: {
@@ -66,10 +104,35 @@ export default {
     active: '启用',
     priority: '优先级',
     command: '命令',
+    timeout: '超时',
+    maxRetries: '最大重试次数',
+    retryDelay: '重试间隔',
+    retryBackoff: '重试退避',
+    resources: '占用资源',
+    dependsOn: '依赖任务',
+    runIfFailed: '失败时运行',
+    always: '始终运行',
+    onFailure: '失败时',
+    exitCodes: '退出码',
     help: {
       active: '决定初始化时该任务是否被加入等待队列',
       priority: '0-31, 越大排序越靠前',
       command: '执行该任务的命令',
+      timeout:
+        '该任务的运行时限（分钟），0表示使用实例的默认值',
+      maxRetries: '该任务运行失败后的重试次数，用完后实例才会被标记为失败',
+      retryDelay: '第一次重试前等待的秒数',
+      retryBackoff:
+        '重试间隔的增长方式：fixed保持不变，linear按重试次数倍增，exponential每次翻倍',
+      resources:
+        '仅在此任务运行期间占用的命名资源，例如“gpu”，任务会等待资源空闲后再运行',
+      dependsOn:
+        '本实例中需先成功完成的任务，多个用逗号分隔，例如“login”；其中任一失败时跳过本任务',
+      runIfFailed: '清理任务，仅在本实例的其他任务失败后运行',
+      always: '清理任务，即使本实例的其他任务失败也会运行，例如退出登录',
+      onFailure: '本任务失败时的处理方式，default使用实例的设置',
+      exitCodes:
+        '本任务退出码的含义，例如“3:skipped, 75:retry”；success和failure分别计为成功和失败，skipped表示无事可做，retry至少再运行一次；未列出的非零退出码视为失败',
     },
   },
   settings: {
@@ -138,4 +201,14 @@ export default {
     restartLater: '稍后重启',
     restarting: '正在重启应用...',
   },
+  shutdown: {
+    title: '正在关闭',
+    waitTask: '正在等待运行中的任务完成，不会再启动新的任务。',
+    finishQueue: '正在等待运行中的实例完成其任务队列。',
+    instances: '运行中：{names}',
+    remaining: '{seconds}秒后将停止剩余任务',
+    noLimit: '完成后关闭，再次关闭可立即停止',
+    closing: '正在关闭应用...',
+    cancel: '取消关闭',
+  },
 };
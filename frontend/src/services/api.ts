@@ -5,6 +5,7 @@ import type {
   RspGetInstance,
   RspWSMessage,
   RspUpdateRepo,
+  RspCronValidate,
   Translation,
   RspSettings,
   UpdateMessage,
@@ -75,6 +76,19 @@ export async function deleteInstance(instanceName: string) {
   handleApiResponse(response);
 }
 
+// POST /api/instance/{instanceName}/task/{taskName}/run
+export async function runTask(
+  instanceName: string,
+  taskName: string,
+  overrides?: Record<string, Record<string, unknown>>,
+) {
+  const response = await api.post<RspApi>(
+    `/instance/${instanceName}/task/${taskName}/run`,
+    { overrides },
+  );
+  handleApiResponse(response);
+}
+
 // PATCH /api/instance/order
 export async function updateInstanceOrder(names: string[]) {
   const response = await api.patch<RspApi>('/instance/order', { names });
@@ -98,6 +112,21 @@ export async function deleteTemplate(templateName: string): Promise<void> {
 let ws: WebSocket | null = null;
 const wsCallbacks: ((data: RspWSMessage) => void)[] = [];
 const updateCallbacks: Map<string, ((data: unknown) => void)[]> = new Map();
+// Topics this client wants, subscribed again whenever the connection opens
+const wsTopics = new Set<string>();
+
+// Topics every view needs, logs are added per instance with logTopic
+export const baseTopics = [
+  'queue',
+  'state',
+  'update',
+  'file_change',
+  'shutdown',
+];
+
+export function logTopic(instanceName: string) {
+  return `log:${instanceName}`;
+}
 
 // Helper function to check if message is an app update message
 function isUpdateMessage(data: unknown): data is UpdateMessage {
@@ -122,6 +151,11 @@ function isUpdateMessage(data: unknown): data is UpdateMessage {
 export function connectWebSocket(callback: (data: RspWSMessage) => void) {
   if (!ws || ws.readyState === WebSocket.CLOSED) {
     ws = new WebSocket('ws://localhost:48596/api/ws');
+    ws.onopen = () => {
+      if (wsTopics.size > 0) {
+        sendSubscription('subscribe', [...wsTopics]);
+      }
+    };
     ws.onmessage = (event) => {
       const data = JSON.parse(event.data);
 
@@ -166,6 +200,7 @@ export function disconnectWebSocket() {
   }
   wsCallbacks.length = 0;
   updateCallbacks.clear();
+  wsTopics.clear();
 }
 
 export function isWebSocketConnected(): boolean {
@@ -207,6 +242,47 @@ export function sendUpdateMessage(messageType: string, data: unknown) {
   }
 }
 
+// Subscribe to or unsubscribe from WebSocket topics, e.g. 'queue', 'state', 'update' or 'log:<instance>'
+// Clients that never subscribe receive all topics
+export function sendSubscription(
+  type: 'subscribe' | 'unsubscribe',
+  topics: string[],
+) {
+  if (ws && ws.readyState === WebSocket.OPEN) {
+    ws.send(JSON.stringify({ type, topics }));
+  } else {
+    console.error('WebSocket is not connected');
+  }
+}
+
+// Replace the subscribed topics, only the difference is sent to the server
+// Topics set before the connection opens are subscribed once it does
+export function setSubscriptions(topics: string[]) {
+  const added = topics.filter((topic) => !wsTopics.has(topic));
+  const removed = [...wsTopics].filter((topic) => !topics.includes(topic));
+  wsTopics.clear();
+  topics.forEach((topic) => wsTopics.add(topic));
+
+  if (!isWebSocketConnected()) {
+    return;
+  }
+  if (removed.length > 0) {
+    sendSubscription('unsubscribe', removed);
+  }
+  if (added.length > 0) {
+    sendSubscription('subscribe', added);
+  }
+}
+
+// Keep the app open after a close request that waits for running instances
+export function cancelShutdown() {
+  if (ws && ws.readyState === WebSocket.OPEN) {
+    ws.send(JSON.stringify({ type: 'shutdown_cancel' }));
+  } else {
+    console.error('WebSocket is not connected');
+  }
+}
+
 // PATCH /api/scheduler/queue
 export async function updateTaskQueue(queues: Record<string, TaskQueue>) {
   const response = await api.patch<RspApi>('/scheduler/queue', { queues });
@@ -220,7 +296,7 @@ export async function getTaskQueue(instanceName: string) {
 
 // PATCH /api/scheduler/state
 export async function updateSchedulerState(
-  type: 'start' | 'stop',
+  type: 'start' | 'stop' | 'pause' | 'resume',
   instanceName?: string,
 ) {
   const response = await api.patch<RspApi>('/scheduler/state', {
@@ -230,6 +306,19 @@ export async function updateSchedulerState(
   handleApiResponse(response);
 }
 
+// POST /api/scheduler/cron/validate
+export async function validateCronExpr(
+  cronExpr: string,
+  timezone?: string,
+  count?: number,
+): Promise<string[]> {
+  const response = await api.post<RspApi & RspCronValidate>(
+    '/scheduler/cron/validate',
+    { cron_expr: cronExpr, timezone, count },
+  );
+  return handleApiResponse(response).next;
+}
+
 // GET /api/updater/{instanceName}
 export async function updateRepo(instanceName: string): Promise<boolean> {
   const response = await api.get<RspApi & RspUpdateRepo>(
@@ -27,10 +27,18 @@ export interface Layout {
   [instanceName: string]: Instance;
 }
 
+export interface TaskState {
+  state: 'succeeded' | 'failed' | 'skipped' | 'timed_out' | 'cancelled';
+  duration: number;
+  exit_code: number;
+  reason: string;
+}
+
 export interface TaskQueue {
   running: string;
   waiting: string[];
   stopped: string[];
+  states?: Record<string, TaskState>;
 }
 
 export interface RspApi {
@@ -76,14 +84,36 @@ export interface RspUpdateRepo {
   is_updated: boolean;
 }
 
+export interface RspCronValidate {
+  next: string[];
+}
+
 export interface RspWSMessage {
-  type: 'queue' | 'log' | 'state' | 'file_change';
+  type:
+    | 'queue'
+    | 'log'
+    | 'log_history'
+    | 'state'
+    | 'file_change'
+    | 'shutdown';
   instance_name: string;
   content?: string;
+  lines?: string[];
   queue?: TaskQueue;
   state?: string;
   filename?: string;
   timestamp?: number;
+  policy?: string;
+  remaining?: number;
+  instances?: string[];
+}
+
+// A close of the app that waits for running instances
+export interface ShutdownInfo {
+  state: 'waiting' | 'closing';
+  policy: string;
+  remaining: number; // Seconds until running instances are stopped, -1 without limit
+  instances: string[];
 }
 
 export interface RspSettings {
@@ -1,6 +1,7 @@
 import { defineStore } from 'pinia';
 import type {
   Layout,
+  ShutdownInfo,
   TaskQueue,
   Translation,
   TranslationMenu,
@@ -293,11 +294,17 @@ export const useSchedulerStore = defineStore('scheduler', {
     schedulerRunning: false, // Store scheduler state
     instanceUpdating: {} as Record<string, boolean>,
     instanceUpdated: {} as Record<string, boolean>,
+    shutdown: null as ShutdownInfo | null, // Close of the app waiting for running instances
   }),
 
   getters: {
     isInstanceRunning: (state) => (instanceName: string) => {
-      return state.states[instanceName] === 'running';
+      // A paused instance is still in the middle of its run
+      return ['running', 'paused'].includes(state.states[instanceName] ?? '');
+    },
+
+    isInstancePaused: (state) => (instanceName: string) => {
+      return state.states[instanceName] === 'paused';
     },
 
     getInstanceState: (state) => (instanceName: string) => {
@@ -314,7 +321,9 @@ export const useSchedulerStore = defineStore('scheduler', {
         return false;
       }
       // Otherwise check if there are any running instances
-      return Object.values(state.states).some((state) => state === 'running');
+      return Object.values(state.states).some(
+        (state) => state === 'running' || state === 'paused',
+      );
     },
 
     isInstanceUpdating: (state) => (instanceName: string) => {
@@ -340,6 +349,22 @@ export const useSchedulerStore = defineStore('scheduler', {
           }
         } else if (data.type === 'log' && data.instance_name && data.content) {
           (this.logs[data.instance_name] ??= []).push(data.content);
+        } else if (data.type === 'log_history' && data.instance_name) {
+          // Replace local logs with the server-side backlog
+          this.logs[data.instance_name] = (data.lines ?? []).filter(
+            (line) => line !== '',
+          );
+        } else if (data.type === 'shutdown') {
+          if (data.state === 'waiting' || data.state === 'closing') {
+            this.shutdown = {
+              state: data.state,
+              policy: data.policy ?? '',
+              remaining: data.remaining ?? -1,
+              instances: data.instances ?? [],
+            };
+          } else {
+            this.shutdown = null;
+          }
         } else if (data.type === 'file_change' && data.instance_name) {
           // Handle file modification notifications
           // Trigger a reload of the instance configuration
@@ -1,7 +1,32 @@
+const descriptors = [
+    '@yearly',
+    '@annually',
+    '@monthly',
+    '@weekly',
+    '@daily',
+    '@midnight',
+    '@hourly',
+];
+
+// Quick client-side check, the backend validates the full syntax
 export const validateCron = (value: string) => {
     if (!value) return true;
 
-    const parts = value.split(' ');
+    // Optional timezone prefix, e.g. "CRON_TZ=Asia/Tokyo 0 4 * * *"
+    let spec = value.trim();
+    const tzMatch = spec.match(/^(CRON_TZ|TZ)=\S+\s+(.*)$/);
+    if (tzMatch) {
+        spec = tzMatch[2] ?? '';
+    }
+
+    if (spec.startsWith('@')) {
+        return (
+            descriptors.includes(spec) ||
+            /^@every\s+(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+$/.test(spec)
+        );
+    }
+
+    const parts = spec.split(/\s+/);
     if (parts.length !== 5) return false;
 
     const patterns = [
@@ -16,4 +41,4 @@ export const validateCron = (value: string) => {
         const pattern = patterns[index];
         return pattern ? pattern.test(part) : false;
     });
-}; 
\ No newline at end of file
+};